@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"time"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DayFormat is the layout of the days of the daily stats, days are in UTC.
+const DayFormat = "2006-01-02"
+
+// rollUpBatch is how many raw views are read at a time by RollUp.
+const rollUpBatch = 1000
+
+// Start launches the worker writing the queued views. It returns right away.
+func Start() {
+	go writeViews()
+}
+
+type dayKey struct {
+	postID   uint
+	day      string
+	referrer string
+}
+
+// RollUp adds the raw views that were not rolled up yet to the daily stats and then
+// removes the raw views that are no longer needed for deduplication.
+func RollUp() error {
+	var maxID uint
+	if err := db.DB.Model(&models.PostView{}).Where("rolled_up = ?", false).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return err
+	}
+
+	if maxID > 0 {
+		counts := make(map[dayKey]int64)
+		var batch []models.PostView
+		err := db.DB.Where("rolled_up = ? AND id <= ?", false, maxID).
+			FindInBatches(&batch, rollUpBatch, func(tx *gorm.DB, _ int) error {
+				for _, view := range batch {
+					counts[dayKey{view.PostID, view.CreatedAt.UTC().Format(DayFormat), view.Referrer}]++
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		err = db.DB.Transaction(func(tx *gorm.DB) error {
+			for key, views := range counts {
+				if err := addViews(tx, key, views); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&models.PostView{}).Where("rolled_up = ? AND id <= ?", false, maxID).Update("rolled_up", true).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.DB.Where("rolled_up = ? AND created_at < ?", true, time.Now().Add(-dedupWindow())).Delete(&models.PostView{}).Error
+}
+
+// addViews adds views to the daily stats of a post and, for referred views, to the
+// stats of the referrer.
+func addViews(tx *gorm.DB, key dayKey, views int64) error {
+	daily := models.PostDailyStat{PostID: key.postID, Day: key.day, Views: views}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{"views": gorm.Expr("post_daily_stats.views + ?", views)}),
+	}).Create(&daily).Error
+	if err != nil || key.referrer == "" {
+		return err
+	}
+
+	referred := models.PostReferrerStat{PostID: key.postID, Day: key.day, Referrer: key.referrer, Views: views}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}, {Name: "referrer"}},
+		DoUpdates: clause.Assignments(map[string]any{"views": gorm.Expr("post_referrer_stats.views + ?", views)}),
+	}).Create(&referred).Error
+}
@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+)
+
+// TrendingWindows are the periods the trending list is computed for.
+var TrendingWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// Weights of the activity counted in a trending score.
+const (
+	viewWeight     = 1.0
+	reactionWeight = 3.0
+	commentWeight  = 5.0
+)
+
+// trendingSize is how many posts are kept per window.
+func trendingSize() int {
+	return settings.TrendingSize
+}
+
+// ComputeTrending scores the published posts for every trending window and replaces
+// the cached rankings.
+func ComputeTrending() error {
+	now := time.Now()
+	for name, window := range TrendingWindows {
+		scores, err := trendingScores(now, window)
+		if err != nil {
+			return err
+		}
+		if err := saveTrending(name, now, scores); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trendingScores sums the weighted activity of the window per post. Every view,
+// reaction and comment loses half of its weight each quarter of the window, so recent
+// activity counts most. Views are taken from the daily stats and dated at midday.
+func trendingScores(now time.Time, window time.Duration) (map[uint]float64, error) {
+	since := now.Add(-window)
+	halfLife := window / 4
+	decay := func(at time.Time) float64 {
+		age := max(now.Sub(at), 0)
+		return math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+
+	published := db.DB.Model(&models.Post{}).Select("id").Where("status = ?", models.StatusPublished)
+	scores := make(map[uint]float64)
+
+	var stats []models.PostDailyStat
+	err := db.DB.Where("post_id IN (?) AND day >= ?", published, since.UTC().Format(DayFormat)).Find(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range stats {
+		day, err := time.Parse(DayFormat, stat.Day)
+		if err != nil {
+			continue
+		}
+		scores[stat.PostID] += viewWeight * float64(stat.Views) * decay(day.Add(12*time.Hour))
+	}
+
+	var events []struct {
+		PostID    uint
+		CreatedAt time.Time
+	}
+	err = db.DB.Model(&models.Reaction{}).Select("target_id AS post_id, created_at").
+		Where("target_type = ? AND target_id IN (?) AND created_at >= ?", models.TargetPost, published, since).
+		Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		scores[event.PostID] += reactionWeight * decay(event.CreatedAt)
+	}
+
+	events = events[:0]
+	err = db.DB.Model(&models.PostComment{}).Select("post_id, created_at").
+		Where("post_id IN (?) AND status = ? AND created_at >= ?", published, models.StatusPublished, since).
+		Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		scores[event.PostID] += commentWeight * decay(event.CreatedAt)
+	}
+
+	return scores, nil
+}
+
+// saveTrending replaces the ranking of a window with the top scored posts.
+func saveTrending(window string, now time.Time, scores map[uint]float64) error {
+	ranking := make([]models.TrendingPost, 0, len(scores))
+	for postID, score := range scores {
+		ranking = append(ranking, models.TrendingPost{Window: window, PostID: postID, Score: score, ComputedAt: now})
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Score != ranking[j].Score {
+			return ranking[i].Score > ranking[j].Score
+		}
+		return ranking[i].PostID > ranking[j].PostID
+	})
+	if size := trendingSize(); len(ranking) > size {
+		ranking = ranking[:size]
+	}
+	for i := range ranking {
+		ranking[i].Rank = i + 1
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("time_window = ?", window).Delete(&models.TrendingPost{}).Error; err != nil {
+			return err
+		}
+		if len(ranking) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(ranking, 100).Error
+	})
+}
@@ -0,0 +1,121 @@
+// Package analytics counts post views. Views are queued by the request handlers,
+// written by a background worker and rolled up into daily stats by a job, so none of
+// this work happens on the request path.
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/utils"
+)
+
+// settings is set from the configuration by Configure.
+var settings = config.Default().Analytics
+
+// Configure sets the view dedup window and the size of the trending rankings.
+func Configure(cfg config.Analytics) {
+	settings = cfg
+}
+
+// View is a view of a post as seen by a request handler.
+type View struct {
+	PostID    uint
+	UserID    uint // 0 for anonymous visitors
+	IP        string
+	UserAgent string
+	Referrer  string
+	At        time.Time
+}
+
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|facebookexternalhit|headless|preview|monitor|python-requests|wget`)
+
+// IsBot reports whether the user agent belongs to a crawler or another automated
+// client. Requests without a user agent count as bots.
+func IsBot(userAgent string) bool {
+	return userAgent == "" || botPattern.MatchString(userAgent)
+}
+
+// views buffers the views between the handlers and the worker. When it is full, views
+// are dropped rather than slowing down requests.
+var views = make(chan View, 1024)
+
+// RecordView queues a view for the worker. Views of bots are ignored.
+func RecordView(view View) {
+	if IsBot(view.UserAgent) {
+		return
+	}
+	select {
+	case views <- view:
+	default:
+		slog.Warn("view queue full, dropping a view", "post_id", view.PostID)
+	}
+}
+
+// dedupWindow is how long repeated views of a post by the same visitor count once.
+func dedupWindow() time.Duration {
+	return settings.ViewDedupWindow.Duration
+}
+
+// writeViews stores the queued views, one at a time so the dedup check can not race.
+func writeViews() {
+	for view := range views {
+		if err := writeView(view); err != nil {
+			slog.Error("could not record a view", "post_id", view.PostID, "error", err)
+		}
+	}
+}
+
+func writeView(view View) error {
+	key := visitorKey(view)
+
+	var recent int64
+	err := db.DB.Model(&models.PostView{}).
+		Where("post_id = ? AND visitor_key = ? AND created_at > ?", view.PostID, key, view.At.Add(-dedupWindow())).
+		Count(&recent).Error
+	if err != nil || recent > 0 {
+		return err
+	}
+
+	return db.DB.Create(&models.PostView{
+		CreatedAt:  view.At,
+		PostID:     view.PostID,
+		VisitorKey: key,
+		Referrer:   referrerHost(view.Referrer),
+	}).Error
+}
+
+// visitorKey identifies a signed in user by id and anyone else by a hash of their IP
+// address and user agent. The hash is keyed with the server secret like
+// audit.Fingerprint: the address is not stored and, the IPv4 space being small enough
+// to hash whole, can't be found again without the secret.
+func visitorKey(view View) string {
+	if view.UserID != 0 {
+		return "user:" + strconv.FormatUint(uint64(view.UserID), 10)
+	}
+	mac := hmac.New(sha256.New, utils.JwtSecret)
+	mac.Write([]byte(view.IP + "|" + view.UserAgent))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// referrerHost keeps the host of the referring page, or nothing for direct visits.
+func referrerHost(referrer string) string {
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	host := parsed.Hostname()
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	return host
+}
@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	"github.com/dayiamin/gin_blog_api/utils"
+)
+
+func TestVisitorKey(t *testing.T) {
+	utils.ConfigureJWT(config.JWT{Secret: "first-secret"})
+	t.Cleanup(func() { utils.ConfigureJWT(config.Default().JWT) })
+	anonymous := View{IP: "203.0.113.7", UserAgent: "Mozilla/5.0"}
+
+	if key := visitorKey(View{UserID: 42, IP: anonymous.IP}); key != "user:42" {
+		t.Errorf("key of a signed in user = %q, want user:42", key)
+	}
+	key := visitorKey(anonymous)
+	if key != visitorKey(anonymous) {
+		t.Error("the key of a visitor changes between views")
+	}
+	if key == visitorKey(View{IP: anonymous.IP, UserAgent: "curl/8.0"}) {
+		t.Error("visitors with another user agent share a key")
+	}
+	plain := sha256.Sum256([]byte(anonymous.IP + "|" + anonymous.UserAgent))
+	if key == hex.EncodeToString(plain[:]) || strings.Contains(key, anonymous.IP) {
+		t.Errorf("key %q can be computed without the server secret", key)
+	}
+
+	utils.ConfigureJWT(config.JWT{Secret: "second-secret"})
+	if key == visitorKey(anonymous) {
+		t.Error("the key does not depend on the server secret")
+	}
+}
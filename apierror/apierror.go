@@ -0,0 +1,170 @@
+// Package apierror describes failed requests as RFC 7807 problem details, each with
+// a stable code clients can act on instead of parsing the message.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Codes of the problems returned by the API. Resources that are not found have codes
+// of their own, see NotFound.
+const (
+	CodeInvalidBody        = "invalid_body"
+	CodeValidation         = "validation_failed"
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidToken       = "invalid_token"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeNotOwner           = "not_owner"
+	CodeBlocked            = "blocked"
+	CodeRemovedByModerator = "removed_by_moderator"
+	CodeSuspended          = "account_suspended"
+	CodeBanned             = "account_banned"
+	CodeUserExists         = "user_exists"
+	CodeCollectionExists   = "collection_exists"
+	CodeAlreadyReported    = "already_reported"
+	CodeReportResolved     = "report_resolved"
+	CodeSelfAction         = "self_action"
+	CodeMaxDepth           = "max_depth_reached"
+	CodeInternal           = "internal_error"
+)
+
+// Error is a problem detail. Type is derived from Code, Instance is filled with the
+// request path when the problem is rendered.
+type Error struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Code     string       `json:"code"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// Extensions are extra members written next to the standard ones.
+	Extensions map[string]any `json:"-"`
+	// Cause is the error behind the problem. It is logged, never rendered.
+	Cause error `json:"-"`
+}
+
+// FieldError is one invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	message := e.Code
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	if e.Cause != nil {
+		message += ": " + e.Cause.Error()
+	}
+	return message
+}
+
+// Unwrap returns the cause of the problem.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// MarshalJSON writes the extensions as members of the problem object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	type problem Error
+	if len(e.Extensions) == 0 {
+		return json.Marshal((*problem)(e))
+	}
+	encoded, err := json.Marshal((*problem)(e))
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]any, len(e.Extensions)+8)
+	for key, value := range e.Extensions {
+		members[key] = value
+	}
+	// the standard members win over extensions of the same name
+	if err := json.Unmarshal(encoded, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// With returns e with an extension member set.
+func (e *Error) With(key string, value any) *Error {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+// Wrap returns e with err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	e.Cause = err
+	return e
+}
+
+// WithDetail returns e with another detail.
+func (e *Error) WithDetail(detail string) *Error {
+	e.Detail = detail
+	return e
+}
+
+func New(status int, code, detail string) *Error {
+	return &Error{
+		Type:   "/errors/" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+func BadRequest(code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+func Unauthorized(code, detail string) *Error {
+	return New(http.StatusUnauthorized, code, detail)
+}
+
+func Forbidden(code, detail string) *Error {
+	return New(http.StatusForbidden, code, detail)
+}
+
+// NotFound is the problem of a missing resource, its code is the resource followed by
+// _not_found, like post_not_found.
+func NotFound(resource string) *Error {
+	return New(http.StatusNotFound, resource+"_not_found", resource+" not found")
+}
+
+func Conflict(code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+// Unprocessable is the problem of a well-formed request the rules do not allow.
+func Unprocessable(code, detail string) *Error {
+	return New(http.StatusUnprocessableEntity, code, detail)
+}
+
+// Internal is the problem of a request that failed on the server, detail says what
+// could not be done without exposing why. The error saying why is added with Wrap.
+func Internal(detail string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, detail)
+}
+
+// From returns err as a problem, errors that are not problems become internal errors
+// so their message never reaches the client.
+func From(err error) *Error {
+	var problem *Error
+	if errors.As(err, &problem) {
+		return problem
+	}
+	return Internal("the request could not be completed").Wrap(err)
+}
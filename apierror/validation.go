@@ -0,0 +1,94 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// report fields by their JSON names, the names clients send
+	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// Invalid is the problem of a request body that could not be bound: a body that
+// breaks the validation rules is unprocessable and lists every invalid field, a body
+// that is not valid JSON is a bad request.
+func Invalid(err error) *Error {
+	var fieldErrors validator.ValidationErrors
+	if errors.As(err, &fieldErrors) {
+		fields := make([]FieldError, 0, len(fieldErrors))
+		for _, fieldError := range fieldErrors {
+			fields = append(fields, FieldError{
+				Field:   fieldError.Field(),
+				Rule:    fieldError.Tag(),
+				Message: message(fieldError),
+			})
+		}
+		return Validation(fields...)
+	}
+
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeError):
+		return BadRequest(CodeInvalidBody, fmt.Sprintf("%s must be a %s", typeError.Field, typeError.Type))
+	case errors.Is(err, io.EOF):
+		return BadRequest(CodeInvalidBody, "the request body is empty")
+	default:
+		return BadRequest(CodeInvalidBody, "the request body is not valid JSON")
+	}
+}
+
+// Validation is the problem of a request body with invalid fields.
+func Validation(fields ...FieldError) *Error {
+	problem := Unprocessable(CodeValidation, "the request body has invalid fields")
+	problem.Errors = fields
+	return problem
+}
+
+// message explains a failed validation rule in words.
+func message(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "min":
+		return bound("at least", param, fieldError.Kind())
+	case "max":
+		return bound("at most", param, fieldError.Kind())
+	default:
+		return "is invalid"
+	}
+}
+
+func bound(limit, param string, kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", limit, param)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must have %s %s items", limit, param)
+	default:
+		return fmt.Sprintf("must be %s %s", limit, param)
+	}
+}
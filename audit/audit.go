@@ -0,0 +1,87 @@
+// Package audit keeps the append-only log of logins, password changes, deletions,
+// role changes and moderation actions, with where each request came from.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Actions written to the audit log.
+const (
+	Login          = "user.login"
+	LoginFailed    = "user.login_failed"
+	PasswordChange = "user.password_change"
+	RoleChange     = "user.role_change"
+	Suspend        = "user.suspend"
+	Ban            = "user.ban"
+	Unsuspend      = "user.unsuspend"
+	PostDelete     = "post.delete"
+	PostRestore    = "post.restore"
+	CommentDelete  = "comment.delete"
+)
+
+// Moderation is the audit action of a moderation action such as hide or approve.
+func Moderation(action string) string {
+	return "moderation." + action
+}
+
+// Fingerprint identifies a value that must not be stored, like the credential of a
+// failed login that may be a mistyped password, so repeated attempts can still be told
+// apart. It is keyed with the server secret, guesses can't be hashed to find it.
+func Fingerprint(value string) string {
+	mac := hmac.New(sha256.New, utils.JwtSecret)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// Entry describes an audited action. ActorID defaults to the authenticated user of
+// the request.
+type Entry struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	Before     any
+	After      any
+}
+
+// Record appends the entry to the audit log together with the IP address, user agent
+// and request id of c. c may be nil for actions not caused by a request. Failures are
+// logged, an action is never undone because it could not be audited.
+func Record(c *gin.Context, entry Entry) {
+	record := models.AuditLog{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+	}
+	if c != nil {
+		if record.ActorID == 0 {
+			record.ActorID = c.GetUint("user_id")
+		}
+		record.IP = c.ClientIP()
+		record.UserAgent = c.Request.UserAgent()
+		record.RequestID = c.GetString("request_id")
+	}
+
+	if err := db.DB.Create(&record).Error; err != nil {
+		logger := slog.Default()
+		if c != nil {
+			logger = logging.FromContext(c.Request.Context())
+		}
+		logger.Error("could not write an audit entry", "action", entry.Action,
+			"target_type", entry.TargetType, "target_id", entry.TargetID, "error", err)
+	}
+}
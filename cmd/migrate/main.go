@@ -0,0 +1,96 @@
+// Command migrate applies, reverts and lists the database migrations. It reads the same
+// configuration as the server.
+//
+//	go run ./cmd/migrate up          apply every pending migration
+//	go run ./cmd/migrate down [n]    revert the last n migrations, 1 by default
+//	go run ./cmd/migrate status      list the migrations and whether they are applied
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/migrations"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn, err := db.Open(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrations.Up(conn)
+		for _, m := range applied {
+			fmt.Println("applied", m.ID())
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to apply")
+		}
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				log.Fatalf("down takes a positive number of migrations, got %q", os.Args[2])
+			}
+		}
+		reverted, err := migrations.Down(conn, steps)
+		for _, m := range reverted {
+			fmt.Println("reverted", m.ID())
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("nothing to revert")
+		}
+	case "status":
+		statuses, err := migrations.Statuses(conn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(out, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+		for _, status := range statuses {
+			appliedAt, note := "pending", ""
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			switch {
+			case status.Unknown:
+				note = "unknown to this build"
+			case status.Changed:
+				note = "changed after it was applied"
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", status.Version, status.Name, appliedAt, note)
+		}
+		out.Flush()
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [n] | status")
+	os.Exit(2)
+}
@@ -0,0 +1,297 @@
+// Package config holds the typed settings of the API. They are loaded once at startup
+// from defaults, an optional YAML or TOML file, a .env file and environment variables,
+// each source overriding the one before.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config is the full configuration of the API.
+type Config struct {
+	Server   Server   `yaml:"server" toml:"server"`
+	Database Database `yaml:"database" toml:"database"`
+	JWT      JWT      `yaml:"jwt" toml:"jwt"`
+	Log      Log      `yaml:"log" toml:"log"`
+
+	Site       Site       `yaml:"site" toml:"site"`
+	Comments   Comments   `yaml:"comments" toml:"comments"`
+	Moderation Moderation `yaml:"moderation" toml:"moderation"`
+	Trash      Trash      `yaml:"trash" toml:"trash"`
+	Analytics  Analytics  `yaml:"analytics" toml:"analytics"`
+	Stream     Stream     `yaml:"stream" toml:"stream"`
+	Metrics    Metrics    `yaml:"metrics" toml:"metrics"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// Addr is the address the server listens on, like ":8080" or "127.0.0.1:80"
+	Addr string `yaml:"addr" toml:"addr"`
+}
+
+// Database drivers.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Database configures the database connection and its pool.
+type Database struct {
+	// Driver is one of sqlite, postgres and mysql
+	Driver string `yaml:"driver" toml:"driver"`
+	// DSN is the data source name, the path of the file for SQLite
+	DSN string `yaml:"dsn" toml:"dsn"`
+	// MaxOpenConns limits the open connections, 0 means no limit
+	MaxOpenConns int `yaml:"max_open_conns" toml:"max_open_conns"`
+	// MaxIdleConns is how many idle connections are kept for reuse
+	MaxIdleConns int `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	// ConnMaxLifetime and ConnMaxIdleTime close connections after that long, 0 keeps them
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	// AutoMigrate applies the pending migrations at startup, when off the server
+	// refuses to start until they are applied with the migrate command
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+// JWT configures the tokens handed out at login.
+type JWT struct {
+	Secret string   `yaml:"secret" toml:"secret"`
+	TTL    Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// Log levels and formats.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Log configures the application logs.
+type Log struct {
+	// Level is the lowest level written: debug, info, warn or error
+	Level string `yaml:"level" toml:"level"`
+	// Format is json for log pipelines or text for reading in a terminal
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Site configures how the API presents itself.
+type Site struct {
+	// URL is the public address of the API the links of feeds and sitemaps start with
+	URL string `yaml:"url" toml:"url"`
+	// FeedSize is how many of the newest posts a feed holds
+	FeedSize int `yaml:"feed_size" toml:"feed_size"`
+	// AdminUsers are given the admin role at startup, so a fresh install has someone
+	// to assign roles
+	AdminUsers []string `yaml:"admin_users" toml:"admin_users"`
+}
+
+// Comments configures comment threads.
+type Comments struct {
+	// MaxDepth is how deep replies nest, top level comments have depth 0
+	MaxDepth int `yaml:"max_depth" toml:"max_depth"`
+}
+
+// Moderation configures the filters screening new content and the reports. A filter
+// set to 0 or left empty is off.
+type Moderation struct {
+	// BlocklistFile holds one blocked word per line, /regex/ for patterns
+	BlocklistFile string `yaml:"blocklist_file" toml:"blocklist_file"`
+	// MaxLinks is the number of links a post or comment may carry
+	MaxLinks int `yaml:"max_links" toml:"max_links"`
+	// DuplicateWindow holds back the same text by the same author within the window
+	DuplicateWindow Duration `yaml:"duplicate_window" toml:"duplicate_window"`
+	// NewAccountAge and NewAccountMaxPerHour rate limit accounts younger than the age
+	NewAccountAge        Duration `yaml:"new_account_age" toml:"new_account_age"`
+	NewAccountMaxPerHour int      `yaml:"new_account_max_per_hour" toml:"new_account_max_per_hour"`
+	// ReportAutoHideThreshold hides content reported by that many distinct users
+	ReportAutoHideThreshold int `yaml:"report_auto_hide_threshold" toml:"report_auto_hide_threshold"`
+	// HideBannedContent leaves the posts and comments of banned users out of listings
+	HideBannedContent bool `yaml:"hide_banned_content" toml:"hide_banned_content"`
+}
+
+// Trash configures how long deleted content stays restorable.
+type Trash struct {
+	// Retention is how long deleted posts stay in the trash, 0 keeps them forever
+	Retention Duration `yaml:"retention" toml:"retention"`
+	// PurgeInterval is how often the trash is purged, 0 turns the purge off
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// Analytics configures view counting and trending posts.
+type Analytics struct {
+	// ViewDedupWindow is how long repeated views by the same visitor count once
+	ViewDedupWindow Duration `yaml:"view_dedup_window" toml:"view_dedup_window"`
+	// RollupInterval is how often views are rolled up into daily stats
+	RollupInterval Duration `yaml:"rollup_interval" toml:"rollup_interval"`
+	// TrendingInterval is how often the trending posts are computed
+	TrendingInterval Duration `yaml:"trending_interval" toml:"trending_interval"`
+	// TrendingSize is how many posts are kept per trending window
+	TrendingSize int `yaml:"trending_size" toml:"trending_size"`
+}
+
+// Stream configures the Server-Sent Events streams.
+type Stream struct {
+	// Heartbeat is how often an idle stream gets a comment line, so proxies keep it open
+	Heartbeat Duration `yaml:"heartbeat" toml:"heartbeat"`
+}
+
+// Metrics configures the Prometheus metrics.
+type Metrics struct {
+	// RefreshInterval is how often the content gauges are counted, 0 turns it off
+	RefreshInterval Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+// Duration is a time.Duration written as a string like "24h" or "90m" in config files.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText parses a duration string, it is used by the YAML and TOML decoders.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	d.Duration = duration
+	return nil
+}
+
+// MarshalText writes the duration as a string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default returns the configuration used for the settings no source sets.
+func Default() Config {
+	return Config{
+		Server:   Server{Addr: ":8080"},
+		Database: Database{Driver: DriverSQLite, DSN: "data.db", MaxIdleConns: 2, AutoMigrate: true},
+		JWT:      JWT{TTL: Duration{24 * time.Hour}},
+		Log:      Log{Level: LevelInfo, Format: FormatJSON},
+
+		Site:     Site{URL: "http://localhost:8080/api/v1", FeedSize: 20},
+		Comments: Comments{MaxDepth: 5},
+		Moderation: Moderation{
+			MaxLinks:                3,
+			DuplicateWindow:         Duration{10 * time.Minute},
+			NewAccountAge:           Duration{24 * time.Hour},
+			NewAccountMaxPerHour:    5,
+			ReportAutoHideThreshold: 5,
+		},
+		Trash: Trash{Retention: Duration{30 * 24 * time.Hour}, PurgeInterval: Duration{time.Hour}},
+		Analytics: Analytics{
+			ViewDedupWindow:  Duration{30 * time.Minute},
+			RollupInterval:   Duration{5 * time.Minute},
+			TrendingInterval: Duration{10 * time.Minute},
+			TrendingSize:     500,
+		},
+		Stream:  Stream{Heartbeat: Duration{15 * time.Second}},
+		Metrics: Metrics{RefreshInterval: Duration{time.Minute}},
+	}
+}
+
+// Validate checks the configuration and reports every invalid setting at once.
+func (cfg Config) Validate() error {
+	var errs []error
+	if strings.TrimSpace(cfg.Server.Addr) == "" {
+		errs = append(errs, errors.New("server.addr (SERVER_ADDR) must not be empty"))
+	}
+	switch cfg.Database.Driver {
+	case DriverSQLite, DriverPostgres, DriverMySQL:
+	default:
+		errs = append(errs, fmt.Errorf("database.driver (DB_DRIVER) must be sqlite, postgres or mysql, got %q", cfg.Database.Driver))
+	}
+	if strings.TrimSpace(cfg.Database.DSN) == "" {
+		errs = append(errs, errors.New("database.dsn (DB_DSN) must not be empty"))
+	}
+	if cfg.Database.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("database.max_open_conns (DB_MAX_OPEN_CONNS) must not be negative"))
+	}
+	if cfg.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database.max_idle_conns (DB_MAX_IDLE_CONNS) must not be negative"))
+	}
+	if cfg.Database.ConnMaxLifetime.Duration < 0 {
+		errs = append(errs, errors.New("database.conn_max_lifetime (DB_CONN_MAX_LIFETIME) must not be negative"))
+	}
+	if cfg.Database.ConnMaxIdleTime.Duration < 0 {
+		errs = append(errs, errors.New("database.conn_max_idle_time (DB_CONN_MAX_IDLE_TIME) must not be negative"))
+	}
+	if cfg.JWT.Secret == "" {
+		errs = append(errs, errors.New("jwt.secret (JWT_SECRET) is required to sign tokens"))
+	}
+	if cfg.JWT.TTL.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("jwt.ttl (JWT_TTL) must be positive, got %s", cfg.JWT.TTL))
+	}
+	switch cfg.Log.Level {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+	default:
+		errs = append(errs, fmt.Errorf("log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", cfg.Log.Level))
+	}
+	switch cfg.Log.Format {
+	case FormatJSON, FormatText:
+	default:
+		errs = append(errs, fmt.Errorf("log.format (LOG_FORMAT) must be json or text, got %q", cfg.Log.Format))
+	}
+
+	if site, err := url.Parse(cfg.Site.URL); err != nil || (site.Scheme != "http" && site.Scheme != "https") || site.Host == "" {
+		errs = append(errs, fmt.Errorf("site.url (SITE_URL) must be an absolute http or https URL, got %q", cfg.Site.URL))
+	}
+	if cfg.Site.FeedSize <= 0 {
+		errs = append(errs, fmt.Errorf("site.feed_size (FEED_SIZE) must be positive, got %d", cfg.Site.FeedSize))
+	}
+	if cfg.Comments.MaxDepth < 0 {
+		errs = append(errs, errors.New("comments.max_depth (MAX_COMMENT_DEPTH) must not be negative"))
+	}
+	if cfg.Moderation.MaxLinks < 0 {
+		errs = append(errs, errors.New("moderation.max_links (MODERATION_MAX_LINKS) must not be negative"))
+	}
+	if cfg.Moderation.DuplicateWindow.Duration < 0 {
+		errs = append(errs, errors.New("moderation.duplicate_window (MODERATION_DUPLICATE_WINDOW) must not be negative"))
+	}
+	if cfg.Moderation.NewAccountAge.Duration < 0 {
+		errs = append(errs, errors.New("moderation.new_account_age (MODERATION_NEW_ACCOUNT_AGE) must not be negative"))
+	}
+	if cfg.Moderation.NewAccountMaxPerHour < 0 {
+		errs = append(errs, errors.New("moderation.new_account_max_per_hour (MODERATION_NEW_ACCOUNT_MAX_PER_HOUR) must not be negative"))
+	}
+	if cfg.Moderation.ReportAutoHideThreshold < 0 {
+		errs = append(errs, errors.New("moderation.report_auto_hide_threshold (REPORT_AUTO_HIDE_THRESHOLD) must not be negative"))
+	}
+	if cfg.Trash.Retention.Duration < 0 {
+		errs = append(errs, errors.New("trash.retention (TRASH_RETENTION) must not be negative"))
+	}
+	if cfg.Trash.PurgeInterval.Duration < 0 {
+		errs = append(errs, errors.New("trash.purge_interval (TRASH_PURGE_INTERVAL) must not be negative"))
+	}
+	if cfg.Analytics.ViewDedupWindow.Duration < 0 {
+		errs = append(errs, errors.New("analytics.view_dedup_window (VIEW_DEDUP_WINDOW) must not be negative"))
+	}
+	if cfg.Analytics.RollupInterval.Duration < 0 {
+		errs = append(errs, errors.New("analytics.rollup_interval (ANALYTICS_ROLLUP_INTERVAL) must not be negative"))
+	}
+	if cfg.Analytics.TrendingInterval.Duration < 0 {
+		errs = append(errs, errors.New("analytics.trending_interval (TRENDING_INTERVAL) must not be negative"))
+	}
+	if cfg.Analytics.TrendingSize <= 0 {
+		errs = append(errs, fmt.Errorf("analytics.trending_size (TRENDING_SIZE) must be positive, got %d", cfg.Analytics.TrendingSize))
+	}
+	if cfg.Stream.Heartbeat.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("stream.heartbeat (SSE_HEARTBEAT) must be positive, got %s", cfg.Stream.Heartbeat))
+	}
+	if cfg.Metrics.RefreshInterval.Duration < 0 {
+		errs = append(errs, errors.New("metrics.refresh_interval (METRICS_REFRESH_INTERVAL) must not be negative"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the configuration. The .env file is optional and never overrides variables
+// already set in the environment. The config file is read from the CONFIG_FILE
+// environment variable, when set, and picked by its extension: .yaml, .yml or .toml.
+func Load() (Config, error) {
+	cfg := Default()
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cfg, fmt.Errorf("reading .env: %w", err)
+	}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if err := loadEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// loadFile decodes a YAML or TOML file over cfg, keys the Config does not know are an
+// error so typos don't go unnoticed.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			var strict *toml.StrictMissingError
+			if errors.As(err, &strict) {
+				return fmt.Errorf("parsing config file %s: %s", path, strict.String())
+			}
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// loadEnv overrides cfg with the environment variables that are set.
+func loadEnv(cfg *Config) error {
+	var errs []error
+	setString := func(key string, dst *string) {
+		if val, ok := os.LookupEnv(key); ok {
+			*dst = val
+		}
+	}
+	setInt := func(key string, dst *int) {
+		if val, ok := os.LookupEnv(key); ok {
+			number, err := strconv.Atoi(val)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid number %q", key, val))
+				return
+			}
+			*dst = number
+		}
+	}
+	setBool := func(key string, dst *bool) {
+		if val, ok := os.LookupEnv(key); ok {
+			flag, err := strconv.ParseBool(val)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid boolean %q", key, val))
+				return
+			}
+			*dst = flag
+		}
+	}
+	setList := func(key string, dst *[]string) {
+		if val, ok := os.LookupEnv(key); ok {
+			*dst = nil
+			for _, item := range strings.Split(val, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+	setDuration := func(key string, dst *Duration) {
+		if val, ok := os.LookupEnv(key); ok {
+			if err := dst.UnmarshalText([]byte(val)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	}
+
+	setString("SERVER_ADDR", &cfg.Server.Addr)
+	setString("DB_DRIVER", &cfg.Database.Driver)
+	setString("DB_DSN", &cfg.Database.DSN)
+	setInt("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	setInt("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	setDuration("DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	setDuration("DB_CONN_MAX_IDLE_TIME", &cfg.Database.ConnMaxIdleTime)
+	setBool("DB_AUTO_MIGRATE", &cfg.Database.AutoMigrate)
+	setString("JWT_SECRET", &cfg.JWT.Secret)
+	setDuration("JWT_TTL", &cfg.JWT.TTL)
+	setString("LOG_LEVEL", &cfg.Log.Level)
+	setString("LOG_FORMAT", &cfg.Log.Format)
+	setString("SITE_URL", &cfg.Site.URL)
+	setInt("FEED_SIZE", &cfg.Site.FeedSize)
+	setList("ADMIN_USERS", &cfg.Site.AdminUsers)
+	setInt("MAX_COMMENT_DEPTH", &cfg.Comments.MaxDepth)
+	setString("MODERATION_BLOCKLIST_FILE", &cfg.Moderation.BlocklistFile)
+	setInt("MODERATION_MAX_LINKS", &cfg.Moderation.MaxLinks)
+	setDuration("MODERATION_DUPLICATE_WINDOW", &cfg.Moderation.DuplicateWindow)
+	setDuration("MODERATION_NEW_ACCOUNT_AGE", &cfg.Moderation.NewAccountAge)
+	setInt("MODERATION_NEW_ACCOUNT_MAX_PER_HOUR", &cfg.Moderation.NewAccountMaxPerHour)
+	setInt("REPORT_AUTO_HIDE_THRESHOLD", &cfg.Moderation.ReportAutoHideThreshold)
+	setBool("HIDE_BANNED_CONTENT", &cfg.Moderation.HideBannedContent)
+	setDuration("TRASH_RETENTION", &cfg.Trash.Retention)
+	setDuration("TRASH_PURGE_INTERVAL", &cfg.Trash.PurgeInterval)
+	setDuration("VIEW_DEDUP_WINDOW", &cfg.Analytics.ViewDedupWindow)
+	setDuration("ANALYTICS_ROLLUP_INTERVAL", &cfg.Analytics.RollupInterval)
+	setDuration("TRENDING_INTERVAL", &cfg.Analytics.TrendingInterval)
+	setInt("TRENDING_SIZE", &cfg.Analytics.TrendingSize)
+	setDuration("SSE_HEARTBEAT", &cfg.Stream.Heartbeat)
+	setDuration("METRICS_REFRESH_INTERVAL", &cfg.Metrics.RefreshInterval)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -1,25 +1,87 @@
 package db
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/dayiamin/gin_blog_api/config"
+	"github.com/dayiamin/gin_blog_api/metrics"
+	"github.com/dayiamin/gin_blog_api/migrations"
 	"github.com/dayiamin/gin_blog_api/models"
-	"gorm.io/driver/sqlite"
 
 	// "github.com/glebarez/sqlite"  //for using pure go for sql
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
-func Connect(){
 
+// Open connects to the database configured by cfg without touching the schema.
+func Open(cfg config.Database) (*gorm.DB, error) {
+	dialect, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialect, &gorm.Config{TranslateError: true, Logger: queryLogger()})
+	if err != nil {
+		return nil, fmt.Errorf("db connection failed: %w", err)
+	}
+	if err := configurePool(db, cfg); err != nil {
+		return nil, fmt.Errorf("db connection failed: %w", err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("db metrics: %w", err)
+	}
+	return db, nil
+}
 
-	db, err := gorm.Open(sqlite.Open("data.db"), &gorm.Config{})
-	if err != nil{
-		log.Fatal("db connections failed")
+// queryLogger writes slow and failed queries to the default logger. Queries are logged
+// with placeholders so values like password hashes stay out of the logs.
+func queryLogger() logger.Interface {
+	return logger.NewSlogLogger(slog.Default(), logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+		ParameterizedQueries:      true,
+	})
+}
+
+// Connect opens the database configured by cfg and sets DB. The pending migrations are
+// applied when cfg.AutoMigrate is set, otherwise they have to be applied with the
+// migrate command first.
+func Connect(cfg config.Database) error {
+	db, err := Open(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.AutoMigrate {
+		applied, err := migrations.Up(db)
+		if err != nil {
+			return fmt.Errorf("db migration failed: %w", err)
+		}
+		for _, m := range applied {
+			slog.Info("applied migration", "migration", m.ID())
+		}
+	} else {
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return fmt.Errorf("db migration check failed: %w", err)
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("the database has %d pending migrations, apply them with `go run ./cmd/migrate up`", len(pending))
+		}
 	}
-	db.AutoMigrate(&models.User{},&models.UserProfile{},&models.Post{},&models.PostComment{})
 	DB = db
+	return nil
+}
 
-	
-}
\ No newline at end of file
+// PromoteAdmins gives the admin role to the users named in userNames, so a fresh
+// install has someone to assign roles.
+func PromoteAdmins(userNames []string) {
+	for _, userName := range userNames {
+		if err := DB.Model(&models.User{}).Where("user_name = ?", userName).Update("role", models.RoleAdmin).Error; err != nil {
+			slog.Error("could not promote a user to admin", "user_name", userName, "error", err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// dialector picks the GORM driver of the configured database.
+func dialector(cfg config.Database) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.DSN), nil
+	case config.DriverPostgres:
+		return postgres.Open(cfg.DSN), nil
+	case config.DriverMySQL:
+		return mysql.Open(cfg.DSN), nil
+	}
+	return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+}
+
+// configurePool applies the connection pool settings to db.
+func configurePool(db *gorm.DB, cfg config.Database) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration)
+	return nil
+}
@@ -15,9 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/post": {
+        "/admin/audit": {
             "get": {
-                "description": "Retrieve a list of all posts with their associated comments.",
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Search the audit log of logins, password changes, deletions, role changes and moderation actions, newest first. since and until take RFC 3339 times. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,109 +30,204 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "admin"
+                ],
+                "summary": "Query the audit log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User who acted, 0 for anonymous and system actions",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. user.login or moderation.hide",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user, post or comment",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the target",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all posts",
                 "responses": {
                     "200": {
-                        "description": "posts: List of posts with comments",
+                        "description": "entries: List of audit entries, page: Current page",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/models.Post"
-                                }
-                            }
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Failed to fetch posts",
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/post/register": {
-            "post": {
+        "/admin/audit/export": {
+            "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Create a new post with image address, title, and caption for the authenticated user. Requires JWT authentication.",
+                "description": "Download the audit entries matching the same filters as the query endpoint as JSON Lines, one entry per line, oldest first. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "posts"
+                    "admin"
                 ],
-                "summary": "Create a new post",
+                "summary": "Export the audit log",
                 "parameters": [
                     {
-                        "description": "Post creation details",
-                        "name": "post",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PostRegister"
-                        }
+                        "type": "integer",
+                        "description": "User who acted, 0 for anonymous and system actions",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. user.login or moderation.hide",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user, post or comment",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the target",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this time",
+                        "name": "until",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "message: Post created, post: Created post data",
+                    "200": {
+                        "description": "One JSON encoded audit entry per line",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "error: Invalid input",
+                        "description": "Invalid time",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "401": {
-                        "description": "error: Unauthorized (missing or invalid JWT)",
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Internal server error (database issue)",
+                        "description": "The export could not be started",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/post/{post_id}": {
-            "delete": {
+        "/admin/suspensions": {
+            "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Delete a post and its associated comments by post ID. Requires JWT authentication.",
+                "description": "Retrieve the users who are banned or whose suspension has not ended yet. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -135,66 +235,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "admin"
                 ],
-                "summary": "Delete a post",
+                "summary": "List suspended users",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Post ID",
-                        "name": "post_id",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Only banned users (true) or only timed suspensions (false)",
+                        "name": "banned",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Post deleted, Post ID: Deleted post ID",
+                        "description": "suspensions: List of suspended users, page: Current page",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "error: Failed to find the post",
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
-                    "401": {
-                        "description": "error: Unauthorized (missing or invalid JWT)",
+                    "403": {
+                        "description": "Missing admin role",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Internal server error (database issue)",
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/post/{post_id}/comments": {
-            "post": {
+        "/admin/trash": {
+            "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Add a comment to a specific post for the authenticated user. Requires JWT authentication.",
+                "description": "Retrieve the deleted posts of every user, or of one user with user_name, newest deletion first. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -202,73 +302,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "admin"
                 ],
-                "summary": "Create a comment",
+                "summary": "List all deleted posts",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
-                        "name": "post_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only the posts of this user",
+                        "name": "user_name",
+                        "in": "query"
                     },
                     {
-                        "description": "Comment details",
-                        "name": "comment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PostCommentsRegister"
-                        }
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "message: Comment added successfully, comment: Created comment data",
+                    "200": {
+                        "description": "posts: Deleted posts, retention_hours: Hours a deleted post stays restorable, page: Current page",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "error: Invalid input",
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
-                    "401": {
-                        "description": "error: Unauthorized (missing or invalid JWT)",
+                    "403": {
+                        "description": "Missing admin role",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Internal server error (post not found or database issue)",
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/post/{post_id}/comments/{comment_id}": {
-            "delete": {
+        "/admin/users/{user_name}/role": {
+            "put": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Delete a comment by its ID for a specific post. Requires JWT authentication.",
+                "description": "Give a user the user, moderator or admin role. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -276,28 +369,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "admin"
                 ],
-                "summary": "Delete a comment",
+                "summary": "Change the role of a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
-                        "name": "post_id",
+                        "description": "Username of the user",
+                        "name": "user_name",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Comment ID",
-                        "name": "comment_id",
-                        "in": "path",
-                        "required": true
+                        "description": "New role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleUpdate"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Comment deleted, comment ID: Deleted comment ID",
+                        "description": "message: Role updated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -306,38 +401,52 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "error: Failed to find the comment",
+                        "description": "Malformed request body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "401": {
-                        "description": "error: Unauthorized (missing or invalid JWT)",
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Internal server error (database issue)",
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/user/login": {
+        "/admin/users/{user_name}/suspension": {
             "post": {
-                "description": "Authenticate a user using username or email and password. Returns a JWT token upon successful login.",
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Suspend a user for the given number of days (default 7), or ban them for good with ban set. Suspended users are rejected on every authenticated request, including with tokens issued before. Requires the admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -345,68 +454,4005 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "User login",
+                "summary": "Suspend or ban a user",
                 "parameters": [
                     {
-                        "description": "Login credentials (username or email and password)",
-                        "name": "credentials",
+                        "type": "string",
+                        "description": "Username of the user",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and length of the suspension",
+                        "name": "suspension",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UserLoginRequest"
+                            "$ref": "#/definitions/models.SuspensionRegister"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Success message, token: JWT token",
+                        "description": "message: User suspended, suspension: Suspension state",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "error: Invalid input",
+                        "description": "Malformed request body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "401": {
-                        "description": "error: Incorrect username/email or password",
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
-                    "500": {
-                        "description": "error: Internal server error (JWT generation)",
+                    "403": {
+                        "description": "Missing admin role",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
-                    }
-                }
-            }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields or suspending yourself",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Let a suspended or banned user back in. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Lift a suspension or a ban",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Suspension lifted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing admin role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unsuspending yourself",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the newest posts of the users the authenticated user follows, leaving out posts and comments of blocked and muted users. Pages are fetched with a cursor, pass the returned next_cursor as before to get the next page. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follows"
+                ],
+                "summary": "Get the home feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cursor, only posts with a smaller id are returned",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "posts: List of posts, next_cursor: Cursor of the next page or null",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed.atom": {
+            "get": {
+                "description": "Retrieve the newest published posts as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the site feed",
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed.json": {
+            "get": {
+                "description": "Retrieve the newest published posts as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the site feed",
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed.rss": {
+            "get": {
+                "description": "Retrieve the newest published posts as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the site feed",
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/analytics": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Views, reactions and comments per post and per day over the last days days, with the sites that referred the most views. Views are rolled up in the background, so the newest ones show up after a few minutes. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get analytics of your posts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of days to cover, 30 by default and at most 365",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "since: First day covered, posts: Analytics per post, referrers: Top referring sites",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/bookmarks": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the authenticated user's bookmarks, newest first, optionally of one collection. Bookmarks of posts that were deleted or are no longer visible stay in the list with available set to false and no post, so they can be removed. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookmarks"
+                ],
+                "summary": "List bookmarks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only bookmarks in this collection",
+                        "name": "collection_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "bookmarks: List of saved posts, count: Number of bookmarks, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/bookmarks/collections": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the authenticated user's bookmark collections with the number of bookmarks in each. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookmarks"
+                ],
+                "summary": "List bookmark collections",
+                "responses": {
+                    "200": {
+                        "description": "collections: Bookmark collections",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.BookmarkCollection"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create an empty named bookmark collection. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookmarks"
+                ],
+                "summary": "Create a bookmark collection",
+                "parameters": [
+                    {
+                        "description": "Collection name",
+                        "name": "collection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BookmarkCollectionRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Collection created, collection: Collection data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "A collection with this name exists",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/bookmarks/collections/{collection_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete one of the authenticated user's bookmark collections. Its bookmarks are kept outside of any collection. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookmarks"
+                ],
+                "summary": "Delete a bookmark collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Collection deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/actions": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Audit trail of the actions taken by moderators and by auto hiding, newest first. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "List moderation actions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "post or comment",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the content",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Moderator who acted, 0 for automatic actions",
+                        "name": "moderator_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "actions: List of moderation actions, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/comments/{comment_id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Publish a comment held back by the moderation filters. Mentioned users and the post or parent comment author are notified at this point, an approved edit of a published comment only notifies the newly mentioned users. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Approve a pending comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional note",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PendingReview"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Content published, action: Recorded moderation action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No pending content with this id",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/comments/{comment_id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Keep a post or a comment held back by the moderation filters hidden for good. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Reject pending content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID, for a comment",
+                        "name": "comment_id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Optional note",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PendingReview"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Content rejected, action: Recorded moderation action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No pending content with this id",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/pending": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Posts and comments held back by the moderation filters, oldest first, with the reason they were held. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "List content waiting for review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "pending: List of pending posts and comments, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/posts/{post_id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Publish a post held back by the moderation filters. Mentioned users are notified at this point. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Approve a pending post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional note",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PendingReview"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Content published, action: Recorded moderation action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No pending content with this id",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/posts/{post_id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Keep a post or a comment held back by the moderation filters hidden for good. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Reject pending content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID, for a post",
+                        "name": "post_id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Optional note",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PendingReview"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Content rejected, action: Recorded moderation action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No pending content with this id",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/reports": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Moderation queue of reports, oldest first. report_count is the number of reports on the same content. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "List reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Report status: open (default), actioned, dismissed or all",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "post or comment",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason code",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Author of the reported content",
+                        "name": "target_user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "reports: List of reports, count: Number of matching reports, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/moderation/reports/{report_id}/action": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Resolve a report with an action on the reported content: hide it, delete it, warn its author, suspend its author for suspend_days (default 7) or dismiss the report. All open reports on the same content are resolved with it and the action is recorded. Requires the moderator or admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Act on a report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Action to take",
+                        "name": "action",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerationActionRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Action applied, action: Recorded moderation action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing moderator role",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Report already resolved",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the authenticated user's notifications, newest first, together with the number of unread ones. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "List notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only return unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "notifications: List of notifications, unread_count: Number of unread notifications, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve which notification types the authenticated user receives. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "preferences: Enabled state per notification type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "object",
+                                "additionalProperties": {
+                                    "type": "boolean"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Turn notification types (comment, reply, mention, follow, reaction) on or off for the authenticated user. Types that are not sent keep their current state. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Update notification preferences",
+                "parameters": [
+                    {
+                        "description": "Enabled state per notification type",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationPreferencesUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "preferences: Enabled state per notification type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "object",
+                                "additionalProperties": {
+                                    "type": "boolean"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown notification type",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read-all": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Mark every unread notification of the authenticated user as read. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark all notifications as read",
+                "responses": {
+                    "200": {
+                        "description": "message: Notifications marked as read, updated: Number of updated notifications",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/stream": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Server-Sent Events stream of the authenticated user's new notifications. Browsers can pass the JWT in the access_token query parameter. Send Last-Event-ID (or last_event_id) to receive the recent events missed since that id. Requires JWT authentication.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Stream notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT, for clients that can not set the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{notification_id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Mark one of the authenticated user's notifications as read. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark a notification as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "notification_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Notification marked as read",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post": {
+            "get": {
+                "description": "Retrieve a list of all posts with their associated comments and reaction counts. When a JWT is sent, my_reaction holds the user's own reaction and content of blocked and muted users is left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get all posts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only the posts filed under this tag",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "posts: List of posts with comments",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Post"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch posts",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/register": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create a new post with image address, title, caption and tags for the authenticated user. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Create a new post",
+                "parameters": [
+                    {
+                        "description": "Post creation details",
+                        "name": "post",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PostRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Post created, post: Created post data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/trash": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the authenticated user's deleted posts, newest deletion first. They can be restored until the retention period (retention_hours) ends and they are purged for good. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "List your deleted posts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "posts: Deleted posts, retention_hours: Hours a deleted post stays restorable, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/trending": {
+            "get": {
+                "description": "Retrieve the posts with the most recent views, reactions and comments, best first. Newer activity weighs more than older activity in the window. The ranking is recomputed in the background every few minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get trending posts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Period to rank, one of 24h, 7d and 30d, 24h by default",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "window: Ranked period, computed_at: Time of the ranking, posts: Ranked posts, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown window",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}": {
+            "get": {
+                "description": "Retrieve one post with its comments and reaction counts. Each request counts as a view for the author's analytics, repeated views by the same visitor within a short window and views by bots are not counted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "post: The post with comments",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Update the image address, title, caption or tags of a post owned by the authenticated user. Empty fields keep their current value, tags are replaced when sent. Mentions in the caption are parsed again and newly mentioned users are notified. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Update a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Post fields to update",
+                        "name": "post",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PostRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Post updated, post: Updated post data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The post belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Move a post of the authenticated user and its comments to the trash by post ID. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Delete a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Post deleted, Post ID: Deleted post ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The post belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/bookmark": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Save a post for later, optionally in a named collection that is created when needed. Bookmarking a post again moves it to the given collection, or out of any collection when none is given. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookmarks"
+                ],
+                "summary": "Bookmark a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Collection name",
+                        "name": "bookmark",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookmarkRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Post bookmarked, bookmark: Bookmark data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove a post from the authenticated user's bookmarks. This also works for bookmarks of posts that were deleted. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookmarks"
+                ],
+                "summary": "Remove a bookmark",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Bookmark removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Add a comment to a specific post for the authenticated user. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Create a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment details",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PostCommentsRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Comment added successfully, comment: Created comment data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The post author blocked the user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments/stream": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Server-Sent Events stream of comment.created and comment.deleted events of a post visible to the authenticated user. Browsers can pass the JWT in the access_token query parameter. Send Last-Event-ID (or last_event_id) to receive the recent events missed since that id. Requires JWT authentication.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Stream comments of a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT, for clients that can not set the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments/{comment_id}": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Update the text of a comment owned by the authenticated user. Mentions are parsed again and newly mentioned users are notified. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Update a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New comment text",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PostCommentsRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Comment updated, comment: Updated comment data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The comment belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete a comment by its ID for a specific post, comments can be deleted by their author and by the author of the post. A comment that still has replies is kept as a \"[deleted]\" placeholder. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Delete a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Comment deleted, comment ID: Deleted comment ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The comment and the post belong to other users",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments/{comment_id}/reactions": {
+            "get": {
+                "description": "Retrieve the users who reacted on a post or a comment, optionally filtered by reaction type. Pending posts are only found with the JWT of their author, hidden content and content of users who blocked the viewer is not found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "List reactions on a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID, only for reactions on a comment",
+                        "name": "comment_id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reaction type filter",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "reactions: Users who reacted and their reaction",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Reactor"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Set the authenticated user's reaction on a post or a comment visible to them, comments must be published on a published post. A user holds one reaction per target, sending another type replaces it and sending the same type again changes nothing. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "React to a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID, only for reactions on a comment",
+                        "name": "comment_id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Reaction type (like, love, laugh, wow, sad, angry)",
+                        "name": "reaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactionRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction saved, reaction: Reaction data, reactions: Counts per reaction type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove the authenticated user's reaction from a post or a comment. Removing a reaction that does not exist succeeds as well. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "Remove a reaction from a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID, only for reactions on a comment",
+                        "name": "comment_id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction removed, reactions: Counts per reaction type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments/{comment_id}/replies": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Add a reply to an existing comment of a post. Replies can be nested up to the configured maximum depth. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Reply to a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Parent comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reply details",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PostCommentsRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Reply added successfully, comment: Created reply data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The post or comment author blocked the user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields or maximum reply depth reached",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments/{comment_id}/report": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Flag a post or a comment visible to the user for the moderators with a reason code (spam, harassment, hate, violence, nudity, misinformation, other). A user can report the same content once. Content reported by enough distinct users is hidden automatically. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Report a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID, only for reporting a comment",
+                        "name": "comment_id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Report reason and note",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Report received, report: Report data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Content already reported by the user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields or reporting own content",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/comments/{comment_id}/thread": {
+            "get": {
+                "description": "Retrieve a comment together with all of its nested replies as a tree, with reaction counts on every comment. When a JWT is sent, replies of blocked and muted users are left out together with their own replies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get a comment thread",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "thread: Comment with nested replies",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.PostComment"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/reactions": {
+            "get": {
+                "description": "Retrieve the users who reacted on a post or a comment, optionally filtered by reaction type. Pending posts are only found with the JWT of their author, hidden content and content of users who blocked the viewer is not found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "List reactions on a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reaction type filter",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "reactions: Users who reacted and their reaction",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Reactor"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Set the authenticated user's reaction on a post or a comment visible to them, comments must be published on a published post. A user holds one reaction per target, sending another type replaces it and sending the same type again changes nothing. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "React to a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reaction type (like, love, laugh, wow, sad, angry)",
+                        "name": "reaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactionRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction saved, reaction: Reaction data, reactions: Counts per reaction type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove the authenticated user's reaction from a post or a comment. Removing a reaction that does not exist succeeds as well. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "Remove a reaction from a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction removed, reactions: Counts per reaction type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/report": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Flag a post or a comment visible to the user for the moderators with a reason code (spam, harassment, hate, violence, nudity, misinformation, other). A user can report the same content once. Content reported by enough distinct users is hidden automatically. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Report a post or a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report reason and note",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Report received, report: Report data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Content already reported by the user",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields or reporting own content",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/post/{post_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Bring a deleted post back from the trash together with the comments deleted along with it. Authors can restore their own posts unless a moderator deleted them, admins can restore any post. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore a deleted post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "post_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Post restored, post: Restored post, comments: Number of restored comments",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "The post belongs to another user or was removed by a moderator",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No deleted post with this id",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemap.xml": {
+            "get": {
+                "description": "Retrieve the XML sitemap of the published posts and the profiles of their authors. Sites with more than 50000 addresses get a sitemap index pointing to /sitemap/{page}.xml instead. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the sitemap",
+                "responses": {
+                    "200": {
+                        "description": "The sitemap or sitemap index",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The sitemap did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemap/{page}": {
+            "get": {
+                "description": "Retrieve one of the sitemaps listed by the sitemap index. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get a page of the sitemap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Page number followed by .xml, like 1.xml",
+                        "name": "page",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The sitemap",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The sitemap did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Sitemap not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/{tag}/feed.atom": {
+            "get": {
+                "description": "Retrieve the newest published posts filed under a tag as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the feed of a tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag name",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/{tag}/feed.json": {
+            "get": {
+                "description": "Retrieve the newest published posts filed under a tag as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the feed of a tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag name",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/{tag}/feed.rss": {
+            "get": {
+                "description": "Retrieve the newest published posts filed under a tag as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the feed of a tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag name",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/blocks": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the users blocked by the authenticated user. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "List blocked users",
+                "responses": {
+                    "200": {
+                        "description": "blocks: Blocked users",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.RelatedUser"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "post": {
+                "description": "Authenticate a user using username or email and password. Returns a JWT token upon successful login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "Login credentials (username or email and password)",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Success message, token: JWT token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Incorrect username/email or password",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended or banned, with the reason and the end of a suspension",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (JWT generation)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mutes": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the users muted by the authenticated user. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "List muted users",
+                "responses": {
+                    "200": {
+                        "description": "mutes: Muted users",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.RelatedUser"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Change the password of the authenticated user, the current password must be sent along. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "passwords",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Password changed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or wrong current password",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/profile": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create a new user profile or update an existing one for the authenticated user. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profiles"
+                ],
+                "summary": "Create or update user profile",
+                "parameters": [
+                    {
+                        "description": "User profile details",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfileRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Profile updated, profile: Updated user profile data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "201": {
+                        "description": "message: Profile created, profile: User profile data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/profile/{user_name}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Retrieve the profile details of a user by their username. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profiles"
+                ],
+                "summary": "Get user profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "profile: User profile data, followers_count: Number of followers, following_count: Number of followed users, is_following, is_blocked, is_muted: Relation of the authenticated user to this user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User or profile not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "description": "Create a new user account with username, email, and password. Returns a JWT token upon successful registration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RegisterUsers"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Success message, token: JWT token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed request body",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "User name or email already taken",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (hashing or database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{user_name}/block": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Block the user with the given username. A blocked user can not comment on your posts, mention you or follow you, existing follows between the two users are removed and neither sees the other's content. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "Block a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user to block",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User blocked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Users can not block themselves",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove the block on the user with the given username. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "Unblock a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user to unblock",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User unblocked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{user_name}/feed.atom": {
+            "get": {
+                "description": "Retrieve the newest published posts of a user as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the feed of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
         },
-        "/user/profile": {
+        "/user/{user_name}/feed.json": {
+            "get": {
+                "description": "Retrieve the newest published posts of a user as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the feed of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{user_name}/feed.rss": {
+            "get": {
+                "description": "Retrieve the newest published posts of a user as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.",
+                "produces": [
+                    "application/rss+xml",
+                    "application/atom+xml",
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Get the feed of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The feed document",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The feed did not change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{user_name}/follow": {
             "post": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Create a new user profile or update an existing one for the authenticated user. Requires JWT authentication.",
+                "description": "Follow the user with the given username. Following a user twice has no effect. Requires JWT authentication.",
                 "consumes": [
                     "application/json"
                 ],
@@ -414,37 +4460,89 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profiles"
+                    "follows"
                 ],
-                "summary": "Create or update user profile",
+                "summary": "Follow a user",
                 "parameters": [
                     {
-                        "description": "User profile details",
-                        "name": "profile",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UserProfileRegister"
-                        }
+                        "type": "string",
+                        "description": "Username of the user to follow",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Profile updated, profile: Updated user profile data",
+                        "description": "message: Now following the user",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "201": {
-                        "description": "message: Profile created, profile: User profile data",
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
-                    "400": {
-                        "description": "error: Invalid input",
+                    "403": {
+                        "description": "The user blocked the follower",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Users can not follow themselves",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Stop following the user with the given username. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follows"
+                ],
+                "summary": "Unfollow a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user to unfollow",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Unfollowed the user",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -453,34 +4551,86 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "error: Unauthorized (missing or invalid JWT)",
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Internal server error (database issue)",
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/user/profile/{user_name}": {
+        "/user/{user_name}/followers": {
             "get": {
-                "security": [
+                "description": "Retrieve the users following the given user, newest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follows"
+                ],
+                "summary": "List followers",
+                "parameters": [
                     {
-                        "JWT": []
+                        "type": "string",
+                        "description": "Username of the user",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
-                "description": "Retrieve the profile details of a user by their username. Requires JWT authentication.",
+                "responses": {
+                    "200": {
+                        "description": "followers: List of users, count: Total number of followers, page: Current page",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{user_name}/following": {
+            "get": {
+                "description": "Retrieve the users the given user follows, newest first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -488,9 +4638,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profiles"
+                    "follows"
                 ],
-                "summary": "Get user profile",
+                "summary": "List followed users",
                 "parameters": [
                     {
                         "type": "string",
@@ -498,42 +4648,51 @@ const docTemplate = `{
                         "name": "user_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "profile: User profile data",
+                        "description": "following: List of users, count: Total number of followed users, page: Current page",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "$ref": "#/definitions/models.UserProfile"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "error: User or profile not found",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
-                    "401": {
-                        "description": "error: Unauthorized (missing or invalid JWT)",
+                    "500": {
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
             }
         },
-        "/user/register": {
+        "/user/{user_name}/mute": {
             "post": {
-                "description": "Create a new user account with username, email, and password. Returns a JWT token upon successful registration.",
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Mute the user with the given username, their posts and comments are hidden from your feed and listings. Requires JWT authentication.",
                 "consumes": [
                     "application/json"
                 ],
@@ -541,23 +4700,83 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "blocks"
                 ],
-                "summary": "Register a new user",
+                "summary": "Mute a user",
                 "parameters": [
                     {
-                        "description": "User registration details",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Username of the user to mute",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User muted",
                         "schema": {
-                            "$ref": "#/definitions/models.RegisterUsers"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Users can not mute themselves",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error (database issue)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove the mute on the user with the given username. Requires JWT authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "Unmute a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user to unmute",
+                        "name": "user_name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "message: Success message, token: JWT token",
+                    "200": {
+                        "description": "message: User unmuted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -565,22 +4784,22 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "error: Invalid input",
+                    "401": {
+                        "description": "Unauthorized (missing or invalid JWT)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     },
                     "500": {
-                        "description": "error: Internal server error (hashing or database issue)",
+                        "description": "Internal server error (database issue)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Error"
                         }
                     }
                 }
@@ -588,9 +4807,172 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "apierror.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apierror.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "apierror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BookmarkCollection": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BookmarkCollectionRegister": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.BookmarkRegister": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.Mention": {
+            "type": "object",
+            "properties": {
+                "length": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModerationActionRegister": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "hide",
+                        "delete",
+                        "warn",
+                        "suspend",
+                        "dismiss"
+                    ]
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "suspend_days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0
+                }
+            }
+        },
+        "models.NotificationPreferencesUpdate": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "boolean"
+            }
+        },
+        "models.PasswordChange": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6
+                }
+            }
+        },
+        "models.PendingReview": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
         "models.Post": {
             "type": "object",
             "properties": {
+                "bookmarked": {
+                    "type": "boolean"
+                },
                 "caption": {
                     "type": "string"
                 },
@@ -600,6 +4982,15 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Mention"
+                    }
+                },
+                "my_reaction": {
+                    "type": "string"
+                },
                 "pic_address": {
                     "type": "string"
                 },
@@ -609,6 +5000,21 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.PostComment"
                     }
                 },
+                "reactions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
                 "title": {
                     "type": "string"
                 },
@@ -626,12 +5032,48 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "depth": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Mention"
+                    }
+                },
+                "my_reaction": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
                 "postID": {
                     "type": "integer"
                 },
+                "reactions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PostComment"
+                    }
+                },
+                "reply_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
                 "text": {
                     "type": "string"
                 },
@@ -665,12 +5107,55 @@ const docTemplate = `{
                 "pic_address": {
                     "type": "string"
                 },
+                "tags": {
+                    "description": "Tags replace the tags of the post, at most MaxPostTags (10) of them, they are kept\non update when left out",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "title": {
                     "type": "string",
                     "maxLength": 250
                 }
             }
         },
+        "models.ReactionRegister": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "like",
+                        "love",
+                        "laugh",
+                        "wow",
+                        "sad",
+                        "angry"
+                    ]
+                }
+            }
+        },
+        "models.Reactor": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_name": {
+                    "type": "string"
+                }
+            }
+        },
         "models.RegisterUsers": {
             "type": "object",
             "required": [
@@ -696,47 +5181,100 @@ const docTemplate = `{
                 }
             }
         },
-        "models.UserLoginRequest": {
+        "models.RelatedUser": {
             "type": "object",
-            "required": [
-                "credential",
-                "password"
-            ],
             "properties": {
-                "credential": {
+                "created_at": {
                     "type": "string"
                 },
-                "password": {
+                "id": {
+                    "type": "integer"
+                },
+                "user_name": {
                     "type": "string"
                 }
             }
         },
-        "models.UserProfile": {
+        "models.ReportRegister": {
             "type": "object",
+            "required": [
+                "reason"
+            ],
             "properties": {
-                "bio": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
                 },
-                "first_name": {
-                    "type": "string"
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "spam",
+                        "harassment",
+                        "hate",
+                        "violence",
+                        "nudity",
+                        "misinformation",
+                        "other"
+                    ]
+                }
+            }
+        },
+        "models.RoleUpdate": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "moderator",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "models.SuspensionRegister": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "ban": {
+                    "type": "boolean"
                 },
-                "id": {
-                    "type": "integer"
+                "days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0
                 },
-                "last_name": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "name": {
                     "type": "string"
-                },
-                "profile_pic": {
+                }
+            }
+        },
+        "models.UserLoginRequest": {
+            "type": "object",
+            "required": [
+                "credential",
+                "password"
+            ],
+            "properties": {
+                "credential": {
                     "type": "string"
                 },
-                "updated_at": {
+                "password": {
                     "type": "string"
-                },
-                "userID": {
-                    "type": "integer"
                 }
             }
         },
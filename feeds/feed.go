@@ -0,0 +1,103 @@
+// Package feeds renders lists of posts as RSS 2.0, Atom and JSON Feed documents.
+package feeds
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	"github.com/dayiamin/gin_blog_api/models"
+)
+
+// Feed is the format independent content of a feed.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the page the feed is about and Self the address of the feed itself
+	Link    string
+	Self    string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is one post of a feed.
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Author    string
+	HTML      string
+	Text      string
+	Image     string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// siteURL is set from the configuration by Configure.
+var siteURL = config.Default().Site.URL
+
+// Configure sets the address the links of feeds and sitemaps start with.
+func Configure(cfg config.Site) {
+	siteURL = strings.TrimSuffix(cfg.URL, "/")
+}
+
+// SiteURL is the address the links of feeds and sitemaps start with. It defaults to
+// the API on localhost.
+func SiteURL() string {
+	return siteURL
+}
+
+// PostURL is the address of a post.
+func PostURL(postID uint) string {
+	return SiteURL() + "/post/" + strconv.FormatUint(uint64(postID), 10)
+}
+
+// AuthorURL is the address of a user's profile.
+func AuthorURL(userName string) string {
+	return SiteURL() + "/user/profile/" + userName
+}
+
+// New builds a feed of posts, authors maps the user IDs of the posts to their names.
+// The feed is as recent as its most recently updated post.
+func New(title, description, link, self string, posts []models.Post, authors map[uint]string) Feed {
+	feed := Feed{Title: title, Description: description, Link: link, Self: self, Items: make([]Item, 0, len(posts))}
+	for _, post := range posts {
+		if post.UpdatedAt.After(feed.Updated) {
+			feed.Updated = post.UpdatedAt
+		}
+		item := Item{
+			ID:        PostURL(post.ID),
+			Title:     post.Title,
+			Link:      PostURL(post.ID),
+			Author:    authors[post.UserID],
+			Text:      post.Caption,
+			HTML:      postHTML(post),
+			Image:     post.PicAddres,
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+		}
+		for _, tag := range post.Tags {
+			item.Tags = append(item.Tags, tag.Name)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed
+}
+
+// postHTML renders the caption and image of a post as HTML, the plain text is escaped
+// so markup typed by users shows as text.
+func postHTML(post models.Post) string {
+	var b strings.Builder
+	if post.PicAddres != "" {
+		b.WriteString(`<p><img src="` + html.EscapeString(post.PicAddres) + `" alt=""></p>`)
+	}
+	for _, paragraph := range strings.Split(post.Caption, "\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			b.WriteString("<p>" + html.EscapeString(paragraph) + "</p>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @Summary Change the role of a user
+// @Description Give a user the user, moderator or admin role. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user"
+// @Param role body models.RoleUpdate true "New role"
+// @Success 200 {object} map[string]string "message: Role updated"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /admin/users/{user_name}/role [put]
+func SetUserRole(c *gin.Context) {
+	var input models.RoleUpdate
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	var user models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&user).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return
+	}
+
+	before := gin.H{"role": user.Role}
+	if err := db.DB.Model(&user).Update("role", input.Role).Error; err != nil {
+		c.Error(apierror.Internal("could not update the role").Wrap(err))
+		return
+	}
+	audit.Record(c, audit.Entry{Action: audit.RoleChange, TargetType: models.TargetUser, TargetID: user.ID, Before: before, After: gin.H{"role": input.Role}})
+
+	c.JSON(http.StatusOK, gin.H{"message": user.UserName + " is now " + input.Role})
+}
+
+// @Summary Suspend or ban a user
+// @Description Suspend a user for the given number of days (default 7), or ban them for good with ban set. Suspended users are rejected on every authenticated request, including with tokens issued before. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user"
+// @Param suspension body models.SuspensionRegister true "Reason and length of the suspension"
+// @Success 200 {object} map[string]interface{} "message: User suspended, suspension: Suspension state"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields or suspending yourself"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /admin/users/{user_name}/suspension [post]
+func SuspendUser(c *gin.Context) {
+	var input models.SuspensionRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	adminID, user, ok := findSuspensionTarget(c)
+	if !ok {
+		return
+	}
+
+	before := suspensionOf(user)
+	action := models.ModerationBan
+	user.Banned = input.Ban
+	user.SuspendedUntil = nil
+	user.SuspensionReason = input.Reason
+	if !input.Ban {
+		days := input.Days
+		if days == 0 {
+			days = defaultSuspendDays
+		}
+		until := time.Now().AddDate(0, 0, days)
+		user.SuspendedUntil = &until
+		action = models.ModerationSuspend
+	}
+
+	if err := saveSuspension(adminID, user, action, input.Reason); err != nil {
+		c.Error(apierror.Internal("could not suspend the user").Wrap(err))
+		return
+	}
+	auditAction := audit.Suspend
+	if user.Banned {
+		auditAction = audit.Ban
+	}
+	audit.Record(c, audit.Entry{Action: auditAction, TargetType: models.TargetUser, TargetID: user.ID, Before: before, After: suspensionOf(user)})
+
+	message := user.UserName + " is suspended"
+	if user.Banned {
+		message = user.UserName + " is banned"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "suspension": suspensionOf(user)})
+}
+
+// @Summary Lift a suspension or a ban
+// @Description Let a suspended or banned user back in. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user"
+// @Success 200 {object} map[string]string "message: Suspension lifted"
+// @Failure 422 {object} apierror.Error "Unsuspending yourself"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /admin/users/{user_name}/suspension [delete]
+func UnsuspendUser(c *gin.Context) {
+	adminID, user, ok := findSuspensionTarget(c)
+	if !ok {
+		return
+	}
+
+	before := suspensionOf(user)
+	user.Banned = false
+	user.SuspendedUntil = nil
+	user.SuspensionReason = ""
+	if err := saveSuspension(adminID, user, models.ModerationUnsuspend, ""); err != nil {
+		c.Error(apierror.Internal("could not lift the suspension").Wrap(err))
+		return
+	}
+	audit.Record(c, audit.Entry{Action: audit.Unsuspend, TargetType: models.TargetUser, TargetID: user.ID, Before: before, After: suspensionOf(user)})
+
+	c.JSON(http.StatusOK, gin.H{"message": user.UserName + " is no longer suspended"})
+}
+
+// @Summary List suspended users
+// @Description Retrieve the users who are banned or whose suspension has not ended yet. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param banned query bool false "Only banned users (true) or only timed suspensions (false)"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "suspensions: List of suspended users, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /admin/suspensions [get]
+func ShowSuspensions(c *gin.Context) {
+	query := db.DB.Model(&models.User{})
+	switch c.Query("banned") {
+	case "true":
+		query = query.Where("banned = ?", true)
+	case "false":
+		query = query.Where("banned = ? AND suspended_until > ?", false, time.Now())
+	default:
+		query = query.Where("banned = ? OR suspended_until > ?", true, time.Now())
+	}
+
+	page, limit, offset := pagination(c)
+	suspensions := []models.Suspension{}
+	err := query.
+		Select("id, user_name, banned, suspended_until, suspension_reason").
+		Order("id").
+		Limit(limit).Offset(offset).
+		Scan(&suspensions).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch suspensions").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suspensions": suspensions, "page": page})
+}
+
+// findSuspensionTarget returns the authenticated admin's id and the user named in the
+// path. It records the error itself.
+func findSuspensionTarget(c *gin.Context) (uint, models.User, bool) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return 0, models.User{}, false
+	}
+	adminID := userIDVal.(uint)
+
+	var user models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&user).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return 0, models.User{}, false
+	}
+	if user.ID == adminID {
+		c.Error(apierror.Unprocessable(apierror.CodeSelfAction, "you can not do this to yourself"))
+		return 0, models.User{}, false
+	}
+	return adminID, user, true
+}
+
+// saveSuspension stores the suspension state of user and records the admin's action.
+func saveSuspension(adminID uint, user models.User, action, note string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user).Select("banned", "suspended_until", "suspension_reason").Updates(map[string]any{
+			"banned":            user.Banned,
+			"suspended_until":   user.SuspendedUntil,
+			"suspension_reason": user.SuspensionReason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.ModerationAction{
+			ModeratorID:  adminID,
+			Action:       action,
+			TargetType:   models.TargetUser,
+			TargetID:     user.ID,
+			TargetUserID: user.ID,
+			Note:         note,
+		}).Error
+	})
+}
+
+func suspensionOf(user models.User) models.Suspension {
+	return models.Suspension{
+		ID:               user.ID,
+		UserName:         user.UserName,
+		Banned:           user.Banned,
+		SuspendedUntil:   user.SuspendedUntil,
+		SuspensionReason: user.SuspensionReason,
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 365
+	topReferrers         = 10
+)
+
+// @Summary Get analytics of your posts
+// @Description Views, reactions and comments per post and per day over the last days days, with the sites that referred the most views. Views are rolled up in the background, so the newest ones show up after a few minutes. Requires JWT authentication.
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param days query int false "Number of days to cover, 30 by default and at most 365"
+// @Success 200 {object} map[string]interface{} "since: First day covered, posts: Analytics per post, referrers: Top referring sites"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /me/analytics [get]
+func ShowAnalytics(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days < 1 {
+		days = defaultAnalyticsDays
+	}
+	days = min(days, maxAnalyticsDays)
+	sinceDay := time.Now().UTC().AddDate(0, 0, 1-days).Truncate(24 * time.Hour)
+	since := sinceDay.Format(analytics.DayFormat)
+
+	var posts []models.Post
+	if err := db.DB.Select("id", "title").Where("user_id = ?", userID).Order("id DESC").Find(&posts).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch analytics").Wrap(err))
+		return
+	}
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	postIDs = append(postIDs, 0)
+
+	// activity[post][day] is filled from the view stats, the reactions and the comments
+	activity := make(map[uint]map[string]*models.DailyActivity)
+	day := func(postID uint, name string) *models.DailyActivity {
+		if activity[postID] == nil {
+			activity[postID] = make(map[string]*models.DailyActivity)
+		}
+		if activity[postID][name] == nil {
+			activity[postID][name] = &models.DailyActivity{Day: name}
+		}
+		return activity[postID][name]
+	}
+
+	var stats []models.PostDailyStat
+	if err := db.DB.Where("post_id IN ? AND day >= ?", postIDs, since).Find(&stats).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch analytics").Wrap(err))
+		return
+	}
+	for _, stat := range stats {
+		day(stat.PostID, stat.Day).Views += stat.Views
+	}
+
+	var events []struct {
+		PostID    uint
+		CreatedAt time.Time
+	}
+	err = db.DB.Model(&models.Reaction{}).Select("target_id AS post_id, created_at").
+		Where("target_type = ? AND target_id IN ? AND created_at >= ?", models.TargetPost, postIDs, sinceDay).
+		Scan(&events).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch analytics").Wrap(err))
+		return
+	}
+	for _, event := range events {
+		day(event.PostID, event.CreatedAt.UTC().Format(analytics.DayFormat)).Reactions++
+	}
+
+	events = events[:0]
+	err = db.DB.Model(&models.PostComment{}).Select("post_id, created_at").
+		Where("post_id IN ? AND created_at >= ?", postIDs, sinceDay).
+		Scan(&events).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch analytics").Wrap(err))
+		return
+	}
+	for _, event := range events {
+		day(event.PostID, event.CreatedAt.UTC().Format(analytics.DayFormat)).Comments++
+	}
+
+	result := make([]models.PostAnalytics, 0, len(posts))
+	for _, post := range posts {
+		summary := models.PostAnalytics{PostID: post.ID, Title: post.Title, Daily: []models.DailyActivity{}}
+		for _, daily := range activity[post.ID] {
+			summary.Views += daily.Views
+			summary.Reactions += daily.Reactions
+			summary.Comments += daily.Comments
+			summary.Daily = append(summary.Daily, *daily)
+		}
+		sort.Slice(summary.Daily, func(i, j int) bool { return summary.Daily[i].Day < summary.Daily[j].Day })
+		result = append(result, summary)
+	}
+
+	referrers := []models.ReferrerCount{}
+	err = db.DB.Model(&models.PostReferrerStat{}).
+		Select("referrer, SUM(views) AS views").
+		Where("post_id IN ? AND day >= ?", postIDs, since).
+		Group("referrer").
+		Order("views DESC").
+		Limit(topReferrers).
+		Scan(&referrers).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch analytics").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"since": since, "posts": result, "referrers": referrers})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditExportBatch is how many audit entries are read at a time during an export.
+const auditExportBatch = 500
+
+// @Summary Query the audit log
+// @Description Search the audit log of logins, password changes, deletions, role changes and moderation actions, newest first. since and until take RFC 3339 times. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param actor_id query int false "User who acted, 0 for anonymous and system actions"
+// @Param action query string false "Action, e.g. user.login or moderation.hide"
+// @Param target_type query string false "user, post or comment"
+// @Param target_id query int false "ID of the target"
+// @Param request_id query string false "Request ID"
+// @Param since query string false "Only entries at or after this time"
+// @Param until query string false "Only entries before this time"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "entries: List of audit entries, page: Current page"
+// @Failure 400 {object} apierror.Error "Invalid time"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /admin/audit [get]
+func ShowAuditLog(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	page, limit, offset := pagination(c)
+	entries := []models.AuditLog{}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch the audit log").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "page": page})
+}
+
+// @Summary Export the audit log
+// @Description Download the audit entries matching the same filters as the query endpoint as JSON Lines, one entry per line, oldest first. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce application/x-ndjson
+// @Security JWT
+// @Param actor_id query int false "User who acted, 0 for anonymous and system actions"
+// @Param action query string false "Action, e.g. user.login or moderation.hide"
+// @Param target_type query string false "user, post or comment"
+// @Param target_id query int false "ID of the target"
+// @Param request_id query string false "Request ID"
+// @Param since query string false "Only entries at or after this time"
+// @Param until query string false "Only entries before this time"
+// @Success 200 {string} string "One JSON encoded audit entry per line"
+// @Failure 400 {object} apierror.Error "Invalid time"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 500 {object} apierror.Error "The export could not be started"
+// @Router /admin/audit/export [get]
+func ExportAuditLog(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	start := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="audit-`+time.Now().UTC().Format("20060102T150405Z")+`.jsonl"`)
+		c.Status(http.StatusOK)
+	}
+
+	// entries are streamed in batches so the export never holds the whole log in memory,
+	// the response starts with the first batch so a failing query still gets an error
+	encoder := json.NewEncoder(c.Writer)
+	var entries []models.AuditLog
+	err := query.FindInBatches(&entries, auditExportBatch, func(tx *gorm.DB, batch int) error {
+		if batch == 1 {
+			start()
+		}
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error
+	switch {
+	case err == nil && !c.Writer.Written():
+		start()
+		c.Writer.WriteHeaderNow()
+	case err != nil && !c.Writer.Written():
+		c.Error(apierror.Internal("could not export the audit log").Wrap(err))
+	case err != nil:
+		// the status is sent already, breaking the connection keeps the client from
+		// taking the truncated file for a complete one
+		logging.FromContext(c.Request.Context()).Error("audit log export failed", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// auditQuery applies the audit log filters of the request. It records the
+// error itself.
+func auditQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := db.DB.Model(&models.AuditLog{})
+	for _, column := range []string{"actor_id", "action", "target_type", "target_id", "request_id"} {
+		if value, ok := c.GetQuery(column); ok && value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	for param, condition := range map[string]string{"since": "created_at >= ?", "until": "created_at < ?"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.Error(apierror.BadRequest(apierror.CodeInvalidParameter, param+" must be an RFC 3339 time"))
+			return nil, false
+		}
+		query = query.Where(condition, at.In(time.Local))
+	}
+	return query, true
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// @Summary Block a user
+// @Description Block the user with the given username. A blocked user can not comment on your posts, mention you or follow you, existing follows between the two users are removed and neither sees the other's content. Requires JWT authentication.
+// @Tags blocks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user to block"
+// @Success 200 {object} map[string]string "message: User blocked"
+// @Failure 422 {object} apierror.Error "Users can not block themselves"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/block [post]
+func BlockUser(c *gin.Context) {
+	userID, target, ok := findRelationTarget(c)
+	if !ok {
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		block := models.Block{UserID: userID, BlockedID: target.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+		return tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)", userID, target.ID, target.ID, userID).
+			Delete(&models.Follow{}).Error
+	})
+	if err != nil {
+		c.Error(apierror.Internal("could not block the user").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "blocked " + target.UserName})
+}
+
+// @Summary Unblock a user
+// @Description Remove the block on the user with the given username. Requires JWT authentication.
+// @Tags blocks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user to unblock"
+// @Success 200 {object} map[string]string "message: User unblocked"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/block [delete]
+func UnblockUser(c *gin.Context) {
+	userID, target, ok := findRelationTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Where("user_id = ? AND blocked_id = ?", userID, target.ID).Delete(&models.Block{}).Error; err != nil {
+		c.Error(apierror.Internal("could not unblock the user").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "unblocked " + target.UserName})
+}
+
+// @Summary Mute a user
+// @Description Mute the user with the given username, their posts and comments are hidden from your feed and listings. Requires JWT authentication.
+// @Tags blocks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user to mute"
+// @Success 200 {object} map[string]string "message: User muted"
+// @Failure 422 {object} apierror.Error "Users can not mute themselves"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/mute [post]
+func MuteUser(c *gin.Context) {
+	userID, target, ok := findRelationTarget(c)
+	if !ok {
+		return
+	}
+
+	mute := models.Mute{UserID: userID, MutedID: target.ID}
+	if err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error; err != nil {
+		c.Error(apierror.Internal("could not mute the user").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "muted " + target.UserName})
+}
+
+// @Summary Unmute a user
+// @Description Remove the mute on the user with the given username. Requires JWT authentication.
+// @Tags blocks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user to unmute"
+// @Success 200 {object} map[string]string "message: User unmuted"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/mute [delete]
+func UnmuteUser(c *gin.Context) {
+	userID, target, ok := findRelationTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Where("user_id = ? AND muted_id = ?", userID, target.ID).Delete(&models.Mute{}).Error; err != nil {
+		c.Error(apierror.Internal("could not unmute the user").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "unmuted " + target.UserName})
+}
+
+// @Summary List blocked users
+// @Description Retrieve the users blocked by the authenticated user. Requires JWT authentication.
+// @Tags blocks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Success 200 {object} map[string][]models.RelatedUser "blocks: Blocked users"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/blocks [get]
+func ShowBlocks(c *gin.Context) {
+	showRelatedUsers(c, "blocks", "blocked_id")
+}
+
+// @Summary List muted users
+// @Description Retrieve the users muted by the authenticated user. Requires JWT authentication.
+// @Tags blocks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Success 200 {object} map[string][]models.RelatedUser "mutes: Muted users"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/mutes [get]
+func ShowMutes(c *gin.Context) {
+	showRelatedUsers(c, "mutes", "muted_id")
+}
+
+func showRelatedUsers(c *gin.Context, table, column string) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	users := []models.RelatedUser{}
+	err := db.DB.Table(table).
+		Select("users.id, users.user_name, "+table+".created_at").
+		Joins("JOIN users ON users.id = "+table+"."+column).
+		Where(table+".user_id = ?", userIDVal.(uint)).
+		Order(table + ".created_at DESC").
+		Scan(&users).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch " + table).Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{table: users})
+}
+
+// findRelationTarget returns the authenticated user id and the other user of a block
+// or mute request. It records the error itself.
+func findRelationTarget(c *gin.Context) (uint, models.User, bool) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return 0, models.User{}, false
+	}
+	userID := userIDVal.(uint)
+
+	var target models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&target).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return 0, models.User{}, false
+	}
+	if target.ID == userID {
+		c.Error(apierror.Unprocessable(apierror.CodeSelfAction, "you can not do this to yourself"))
+		return 0, models.User{}, false
+	}
+	return userID, target, true
+}
+
+// isBlocked reports whether ownerID has blocked actorID.
+func isBlocked(ownerID, actorID uint) (bool, error) {
+	var count int64
+	err := db.DB.Model(&models.Block{}).Where("user_id = ? AND blocked_id = ?", ownerID, actorID).Count(&count).Error
+	return count > 0, err
+}
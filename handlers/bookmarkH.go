@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// @Summary Bookmark a post
+// @Description Save a post for later, optionally in a named collection that is created when needed. Bookmarking a post again moves it to the given collection, or out of any collection when none is given. Requires JWT authentication.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param bookmark body models.BookmarkRegister false "Collection name"
+// @Success 200 {object} map[string]interface{} "message: Post bookmarked, bookmark: Bookmark data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Post not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/bookmark [put]
+func SetBookmark(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.BookmarkRegister
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBind(&input); err != nil {
+			c.Error(apierror.Invalid(err))
+			return
+		}
+	}
+
+	var post models.Post
+	if err := db.DB.Scopes(visibleTo(userID)).Where("id = ?", c.Param("post_id")).First(&post).Error; err != nil {
+		c.Error(apierror.NotFound("post"))
+		return
+	}
+
+	bookmark := models.Bookmark{UserID: userID, PostID: post.ID}
+	if input.Collection != "" {
+		collection, err := findOrCreateCollection(userID, input.Collection)
+		if err != nil {
+			c.Error(apierror.Internal("could not save the bookmark").Wrap(err))
+			return
+		}
+		bookmark.CollectionID = &collection.ID
+	}
+
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+	}).Create(&bookmark).Error
+	if err == nil {
+		// reload, on conflict the row keeps its original created_at
+		err = db.DB.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&bookmark).Error
+	}
+	if err != nil {
+		c.Error(apierror.Internal("could not save the bookmark").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post bookmarked", "bookmark": bookmark})
+}
+
+// @Summary Remove a bookmark
+// @Description Remove a post from the authenticated user's bookmarks. This also works for bookmarks of posts that were deleted. Requires JWT authentication.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Success 200 {object} map[string]string "message: Bookmark removed"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/bookmark [delete]
+func RemoveBookmark(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	if err := db.DB.Where("user_id = ? AND post_id = ?", userIDVal.(uint), c.Param("post_id")).Delete(&models.Bookmark{}).Error; err != nil {
+		c.Error(apierror.Internal("could not remove the bookmark").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "bookmark removed"})
+}
+
+// @Summary List bookmarks
+// @Description Retrieve the authenticated user's bookmarks, newest first, optionally of one collection. Bookmarks of posts that were deleted or are no longer visible stay in the list with available set to false and no post, so they can be removed. Requires JWT authentication.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param collection_id query int false "Only bookmarks in this collection"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "bookmarks: List of saved posts, count: Number of bookmarks, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /me/bookmarks [get]
+func ShowBookmarks(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	query := db.DB.Model(&models.Bookmark{}).Where("user_id = ?", userID)
+	if collectionID := c.Query("collection_id"); collectionID != "" {
+		query = query.Where("collection_id = ?", collectionID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
+		return
+	}
+
+	page, limit, offset := pagination(c)
+	var bookmarks []models.Bookmark
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&bookmarks).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
+		return
+	}
+
+	postIDs := make([]uint, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		postIDs = append(postIDs, bookmark.PostID)
+	}
+	var posts []models.Post
+	if err := db.DB.Scopes(visibleTo(userID)).Preload("Mentions").Preload("Tags").Where("id IN ?", append(postIDs, 0)).Find(&posts).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
+		return
+	}
+	if err := attachPostReactions(posts, userID); err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
+	}
+	byID := make(map[uint]*models.Post, len(posts))
+	for i := range posts {
+		posts[i].Bookmarked = true
+		byID[posts[i].ID] = &posts[i]
+	}
+
+	saved := make([]models.SavedPost, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		post := byID[bookmark.PostID]
+		saved = append(saved, models.SavedPost{
+			PostID:       bookmark.PostID,
+			CollectionID: bookmark.CollectionID,
+			CreatedAt:    bookmark.CreatedAt,
+			Available:    post != nil,
+			Post:         post,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bookmarks": saved, "count": count, "page": page})
+}
+
+// @Summary List bookmark collections
+// @Description Retrieve the authenticated user's bookmark collections with the number of bookmarks in each. Requires JWT authentication.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Success 200 {object} map[string][]models.BookmarkCollection "collections: Bookmark collections"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /me/bookmarks/collections [get]
+func ShowBookmarkCollections(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	collections := []models.BookmarkCollection{}
+	err := db.DB.Model(&models.BookmarkCollection{}).
+		Select("bookmark_collections.*, (SELECT COUNT(*) FROM bookmarks WHERE bookmarks.collection_id = bookmark_collections.id) AS count").
+		Where("user_id = ?", userIDVal.(uint)).
+		Order("name").
+		Find(&collections).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch collections").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// @Summary Create a bookmark collection
+// @Description Create an empty named bookmark collection. Requires JWT authentication.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param collection body models.BookmarkCollectionRegister true "Collection name"
+// @Success 201 {object} map[string]interface{} "message: Collection created, collection: Collection data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 409 {object} apierror.Error "A collection with this name exists"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /me/bookmarks/collections [post]
+func CreateBookmarkCollection(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var input models.BookmarkCollectionRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	collection := models.BookmarkCollection{UserID: userIDVal.(uint), Name: input.Name}
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&collection)
+	if result.Error != nil {
+		c.Error(apierror.Internal("could not create the collection").Wrap(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apierror.Conflict(apierror.CodeCollectionExists, "you already have a collection with this name"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "collection created", "collection": collection})
+}
+
+// @Summary Delete a bookmark collection
+// @Description Delete one of the authenticated user's bookmark collections. Its bookmarks are kept outside of any collection. Requires JWT authentication.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param collection_id path string true "Collection ID"
+// @Success 200 {object} map[string]string "message: Collection deleted"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Collection not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /me/bookmarks/collections/{collection_id} [delete]
+func DeleteBookmarkCollection(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var collection models.BookmarkCollection
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("collection_id"), userIDVal.(uint)).First(&collection).Error; err != nil {
+		c.Error(apierror.NotFound("collection"))
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+	if err != nil {
+		c.Error(apierror.Internal("could not delete the collection").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "collection deleted"})
+}
+
+// findOrCreateCollection returns the user's collection with the given name, creating it
+// when it does not exist yet.
+func findOrCreateCollection(userID uint, name string) (models.BookmarkCollection, error) {
+	collection := models.BookmarkCollection{UserID: userID, Name: name}
+	if err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&collection).Error; err != nil {
+		return collection, err
+	}
+	err := db.DB.Where("user_id = ? AND name = ?", userID, name).First(&collection).Error
+	return collection, err
+}
+
+// attachBookmarks sets the bookmarked flag of the posts the viewer saved.
+func attachBookmarks(posts []models.Post, viewerID uint) error {
+	if viewerID == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	var saved []uint
+	if err := db.DB.Model(&models.Bookmark{}).Where("user_id = ? AND post_id IN ?", viewerID, postIDs).Pluck("post_id", &saved).Error; err != nil {
+		return err
+	}
+
+	bookmarked := make(map[uint]bool, len(saved))
+	for _, postID := range saved {
+		bookmarked[postID] = true
+	}
+	for i := range posts {
+		posts[i].Bookmarked = bookmarked[posts[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/moderation"
+	"github.com/dayiamin/gin_blog_api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CommentHandler serves the comment endpoints on top of a CommentService.
+type CommentHandler struct {
+	comments *services.CommentService
+}
+
+func NewCommentHandler(comments *services.CommentService) *CommentHandler {
+	return &CommentHandler{comments: comments}
+}
+
+// @Summary Create a comment
+// @Description Add a comment to a specific post for the authenticated user. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment body models.PostCommentsRegister true "Comment details"
+// @Success 201 {object} map[string]interface{} "message: Comment added successfully, comment: Created comment data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The post author blocked the user"
+// @Failure 404 {object} apierror.Error "Post not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/comments [post]
+func (h *CommentHandler) RegisterComment(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.PostCommentsRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	status, verdict, ok := screen(c, moderation.Content{Type: models.TargetComment, AuthorID: userID, Text: input.Text})
+	if !ok {
+		return
+	}
+	comment, err := h.comments.Create(userID, idParam(c, "post_id"), input.Text, status)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("post"))
+		return
+	case errors.Is(err, services.ErrBlocked):
+		c.Error(apierror.Forbidden(apierror.CodeBlocked, "you can not comment on this post"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not create comment").Wrap(err))
+		return
+	}
+
+	if verdict != nil {
+		holdForReview(models.TargetComment, comment.ID, userID, verdict)
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Comment is waiting for moderator review",
+			"comment": comment,
+		})
+		return
+	}
+	h.announce(&comment)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment added successfully",
+		"comment": comment,
+	})
+}
+
+// @Summary Delete a comment
+// @Description Delete a comment by its ID for a specific post, comments can be deleted by their author and by the author of the post. A comment that still has replies is kept as a "[deleted]" placeholder. Requires JWT authentication.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string true "Comment ID"
+// @Success 200 {object} map[string]string "message: Comment deleted, comment ID: Deleted comment ID"
+// @Failure 404 {object} apierror.Error "Comment not found"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The comment and the post belong to other users"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/comments/{comment_id} [delete]
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	commentID := c.Param("comment_id")
+
+	commentDB, placeholder, err := h.comments.Delete(userIDVal.(uint), idParam(c, "post_id"), idParam(c, "comment_id"))
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("comment"))
+		return
+	case errors.Is(err, services.ErrForbidden):
+		c.Error(apierror.Forbidden(apierror.CodeNotOwner, "you can only delete your own comments or comments on your posts"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not delete the comment").Wrap(err))
+		return
+	}
+	publishCommentDeleted(&commentDB, placeholder)
+	audit.Record(c, audit.Entry{Action: audit.CommentDelete, TargetType: models.TargetComment, TargetID: commentDB.ID, Before: commentDB})
+	c.JSON(http.StatusOK, gin.H{"message": "comment Deleted", "comment ID": commentID})
+}
+
+// @Summary Reply to a comment
+// @Description Add a reply to an existing comment of a post. Replies can be nested up to the configured maximum depth. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string true "Parent comment ID"
+// @Param comment body models.PostCommentsRegister true "Reply details"
+// @Success 201 {object} map[string]interface{} "message: Reply added successfully, comment: Created reply data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields or maximum reply depth reached"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The post or comment author blocked the user"
+// @Failure 404 {object} apierror.Error "Comment not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/comments/{comment_id}/replies [post]
+func (h *CommentHandler) RegisterReply(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.PostCommentsRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	status, verdict, ok := screen(c, moderation.Content{Type: models.TargetComment, AuthorID: userID, Text: input.Text})
+	if !ok {
+		return
+	}
+
+	reply, err := h.comments.Reply(userID, idParam(c, "post_id"), idParam(c, "comment_id"), input.Text, status)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("comment"))
+		return
+	case errors.Is(err, services.ErrBlocked):
+		c.Error(apierror.Forbidden(apierror.CodeBlocked, "you can not reply to this comment"))
+		return
+	case errors.Is(err, services.ErrMaxDepth):
+		c.Error(apierror.Unprocessable(apierror.CodeMaxDepth, "maximum reply depth reached"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not create reply").Wrap(err))
+		return
+	}
+
+	if verdict != nil {
+		holdForReview(models.TargetComment, reply.ID, userID, verdict)
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Reply is waiting for moderator review",
+			"comment": reply,
+		})
+		return
+	}
+	h.announce(&reply)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Reply added successfully",
+		"comment": reply,
+	})
+}
+
+// @Summary Update a comment
+// @Description Update the text of a comment owned by the authenticated user. Mentions are parsed again and newly mentioned users are notified. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string true "Comment ID"
+// @Param comment body models.PostCommentsRegister true "New comment text"
+// @Success 200 {object} map[string]interface{} "message: Comment updated, comment: Updated comment data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The comment belongs to another user"
+// @Failure 404 {object} apierror.Error "Comment not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/comments/{comment_id} [put]
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.PostCommentsRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	comment, err := h.comments.Edit(userID, idParam(c, "post_id"), idParam(c, "comment_id"))
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("comment"))
+		return
+	case errors.Is(err, services.ErrForbidden):
+		c.Error(apierror.Forbidden(apierror.CodeNotOwner, "you can only update your own comments"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not update comment").Wrap(err))
+		return
+	}
+
+	_, verdict, ok := screen(c, moderation.Content{Type: models.TargetComment, ID: comment.ID, AuthorID: userID, Text: input.Text})
+	if !ok {
+		return
+	}
+	if err := h.comments.Update(&comment, input.Text, verdict != nil); err != nil {
+		c.Error(apierror.Internal("could not update comment").Wrap(err))
+		return
+	}
+	if verdict != nil {
+		holdForReview(models.TargetComment, comment.ID, userID, verdict)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Comment is waiting for moderator review",
+			"comment": comment,
+		})
+		return
+	}
+	comment.Mentions = mentionsOf(models.TargetComment, comment.ID, comment.Text, userID, comment.PostID, &comment.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+		"comment": comment,
+	})
+}
+
+// @Summary Get a comment thread
+// @Description Retrieve a comment together with all of its nested replies as a tree, with reaction counts on every comment. When a JWT is sent, replies of blocked and muted users are left out together with their own replies.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string true "Comment ID"
+// @Success 200 {object} map[string]models.PostComment "thread: Comment with nested replies"
+// @Failure 404 {object} apierror.Error "Comment not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/comments/{comment_id}/thread [get]
+func (h *CommentHandler) ShowCommentThread(c *gin.Context) {
+	viewerID := currentUserID(c)
+
+	root, descendants, err := h.comments.Thread(viewerID, idParam(c, "post_id"), idParam(c, "comment_id"))
+	if errors.Is(err, services.ErrNotFound) {
+		c.Error(apierror.NotFound("comment"))
+		return
+	}
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch replies").Wrap(err))
+		return
+	}
+
+	comments := []*models.PostComment{&root}
+	for i := range descendants {
+		comments = append(comments, &descendants[i])
+	}
+	if err := attachCommentReactions(comments, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"thread": buildCommentTree(root, descendants)})
+}
+
+// buildCommentTree nests the given descendants under root using their ParentID.
+func buildCommentTree(root models.PostComment, descendants []models.PostComment) models.PostComment {
+	children := make(map[uint][]models.PostComment)
+	for _, comment := range descendants {
+		if comment.ParentID != nil {
+			children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+		}
+	}
+	return attachReplies(root, children)
+}
+
+func attachReplies(node models.PostComment, children map[uint][]models.PostComment) models.PostComment {
+	for _, child := range children[node.ID] {
+		node.Replies = append(node.Replies, attachReplies(child, children))
+	}
+	return node
+}
+
+// @Summary Approve a pending comment
+// @Description Publish a comment held back by the moderation filters. Mentioned users and the post or parent comment author are notified at this point, an approved edit of a published comment only notifies the newly mentioned users. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param comment_id path string true "Comment ID"
+// @Param review body models.PendingReview false "Optional note"
+// @Success 200 {object} map[string]interface{} "message: Content published, action: Recorded moderation action"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 404 {object} apierror.Error "No pending content with this id"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/comments/{comment_id}/approve [post]
+func (h *CommentHandler) ApproveComment(c *gin.Context) {
+	reviewPending(c, models.ModerationApprove, models.StatusPublished, h.announce)
+}
+
+// announce parses the mentions of a newly published comment, streams it to the
+// clients of its post and notifies the post author, or the parent's author for a reply.
+func (h *CommentHandler) announce(comment *models.PostComment) {
+	comment.Mentions = mentionsOf(models.TargetComment, comment.ID, comment.Text, comment.UserID, comment.PostID, &comment.ID)
+	publishCommentEvent(CommentCreatedEvent, comment.PostID, *comment)
+
+	recipientID, err := h.comments.Recipient(*comment)
+	if err != nil || recipientID == 0 {
+		return
+	}
+	notification := models.Notification{
+		UserID:    recipientID,
+		ActorID:   comment.UserID,
+		Type:      models.NotificationComment,
+		PostID:    &comment.PostID,
+		CommentID: &comment.ID,
+	}
+	if comment.ParentID != nil {
+		notification.Type = models.NotificationReply
+	}
+	notify(notification)
+}
+
+// publishCommentDeleted tells the clients streaming the post that a comment was
+// removed, or replaced by a placeholder.
+func publishCommentDeleted(comment *models.PostComment, placeholder bool) {
+	publishCommentEvent(CommentDeletedEvent, comment.PostID, gin.H{"id": comment.ID, "post_id": comment.PostID, "placeholder": placeholder})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// @Summary Follow a user
+// @Description Follow the user with the given username. Following a user twice has no effect. Requires JWT authentication.
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user to follow"
+// @Success 200 {object} map[string]string "message: Now following the user"
+// @Failure 422 {object} apierror.Error "Users can not follow themselves"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The user blocked the follower"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/follow [post]
+func FollowUser(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var followee models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&followee).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return
+	}
+	if followee.ID == userID {
+		c.Error(apierror.Unprocessable(apierror.CodeSelfAction, "you can not follow yourself"))
+		return
+	}
+	if blocked, err := isBlocked(followee.ID, userID); err != nil || blocked {
+		c.Error(apierror.Forbidden(apierror.CodeBlocked, "you can not follow this user"))
+		return
+	}
+
+	follow := models.Follow{FollowerID: userID, FolloweeID: followee.ID}
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow)
+	if result.Error != nil {
+		c.Error(apierror.Internal("could not follow the user").Wrap(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		notify(models.Notification{UserID: followee.ID, ActorID: userID, Type: models.NotificationFollow})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "following " + followee.UserName})
+}
+
+// @Summary Unfollow a user
+// @Description Stop following the user with the given username. Requires JWT authentication.
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name path string true "Username of the user to unfollow"
+// @Success 200 {object} map[string]string "message: Unfollowed the user"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/follow [delete]
+func UnfollowUser(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var followee models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&followee).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return
+	}
+
+	if err := db.DB.Where("follower_id = ? AND followee_id = ?", userID, followee.ID).Delete(&models.Follow{}).Error; err != nil {
+		c.Error(apierror.Internal("could not unfollow the user").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "unfollowed " + followee.UserName})
+}
+
+// @Summary List followers
+// @Description Retrieve the users following the given user, newest first.
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Param user_name path string true "Username of the user"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "followers: List of users, count: Total number of followers, page: Current page"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/followers [get]
+func ShowFollowers(c *gin.Context) {
+	showFollowList(c, "followee_id", "follower_id", "followers")
+}
+
+// @Summary List followed users
+// @Description Retrieve the users the given user follows, newest first.
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Param user_name path string true "Username of the user"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "following: List of users, count: Total number of followed users, page: Current page"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/following [get]
+func ShowFollowing(c *gin.Context) {
+	showFollowList(c, "follower_id", "followee_id", "following")
+}
+
+// showFollowList lists the users on the other side of the follow edges where the
+// requested user is in matchColumn.
+func showFollowList(c *gin.Context, matchColumn, listColumn, key string) {
+	var user models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&user).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return
+	}
+
+	var count int64
+	if err := db.DB.Model(&models.Follow{}).Where(matchColumn+" = ?", user.ID).Count(&count).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch " + key).Wrap(err))
+		return
+	}
+
+	page, limit, offset := pagination(c)
+	users := []models.FollowUser{}
+	err := db.DB.Table("follows").
+		Select("users.id, users.user_name, follows.created_at AS followed_at").
+		Joins("JOIN users ON users.id = follows."+listColumn).
+		Where("follows."+matchColumn+" = ?", user.ID).
+		Order("follows.created_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&users).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch " + key).Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{key: users, "count": count, "page": page})
+}
+
+// @Summary Get the home feed
+// @Description Retrieve the newest posts of the users the authenticated user follows, leaving out posts and comments of blocked and muted users. Pages are fetched with a cursor, pass the returned next_cursor as before to get the next page. Requires JWT authentication.
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param before query int false "Cursor, only posts with a smaller id are returned"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "posts: List of posts, next_cursor: Cursor of the next page or null"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /feed [get]
+func ShowFeed(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+	limit := pageSize(c)
+
+	// the followed ids stay in a subquery and pages use a keyset cursor, so neither
+	// the number of follows nor the page depth grows the query
+	followees := db.DB.Model(&models.Follow{}).Select("followee_id").Where("follower_id = ?", userID)
+	query := db.DB.Scopes(visibleTo(userID)).
+		Preload("Mentions").
+		Preload("PostComment", visibleTo(userID)).
+		Preload("PostComment.Mentions").
+		Where("user_id IN (?)", followees)
+	if before, err := strconv.ParseUint(c.Query("before"), 10, 64); err == nil && before > 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	posts := []models.Post{}
+	if err := query.Order("id DESC").Limit(limit).Find(&posts).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch feed").Wrap(err))
+		return
+	}
+	if err := attachPostReactions(posts, userID); err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
+	}
+	if err := attachBookmarks(posts, userID); err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
+		return
+	}
+
+	var nextCursor *uint
+	if len(posts) == limit {
+		nextCursor = &posts[len(posts)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts, "next_cursor": nextCursor})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// settings is set from the configuration by Configure.
+var settings = config.Default()
+
+// Configure sets the feed size, the stream heartbeat and the report threshold the
+// handlers use.
+func Configure(cfg config.Config) {
+	settings = cfg
+}
+
+// currentUserID returns the id set by the JWT middlewares, or 0 for anonymous requests.
+func currentUserID(c *gin.Context) uint {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		return 0
+	}
+	return userIDVal.(uint)
+}
+
+// idParam returns the ID in a path parameter, or 0 when it is not a valid ID, which
+// matches no record.
+func idParam(c *gin.Context, name string) uint {
+	id, err := strconv.ParseUint(c.Param(name), 10, 0)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// hasRole reports whether the user has one of the given roles.
+func hasRole(userID uint, roles ...string) (bool, error) {
+	var count int64
+	err := db.DB.Model(&models.User{}).Where("id = ? AND role IN ?", userID, roles).Count(&count).Error
+	return count > 0, err
+}
+
+// pagination reads the page and limit query parameters and returns the page number,
+// the page size and the offset to use in the query.
+func pagination(c *gin.Context) (int, int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return page, pageSize(c), (page - 1) * pageSize(c)
+}
+
+func pageSize(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		return defaultPageSize
+	}
+	return min(limit, maxPageSize)
+}
+
+// contentTarget is the post or comment a reaction or report request points to.
+type contentTarget struct {
+	Type      string
+	ID        uint
+	OwnerID   uint
+	Status    string
+	PostID    uint
+	CommentID *uint
+}
+
+// findContentTarget resolves the post, or the comment when the route has a comment_id,
+// that a request points to. Posts must be visible to the current user, comments must
+// be visible and published on a visible, published post. It records the error itself.
+func findContentTarget(c *gin.Context) (contentTarget, bool) {
+	viewerID := currentUserID(c)
+	if commentID := c.Param("comment_id"); commentID != "" {
+		posts := db.DB.Model(&models.Post{}).Select("id").Scopes(visibleTo(viewerID)).Where("status = ?", models.StatusPublished)
+		var comment models.PostComment
+		err := db.DB.Scopes(visibleTo(viewerID)).
+			Where("id = ? AND post_id = ? AND deleted = ? AND status = ?", commentID, c.Param("post_id"), false, models.StatusPublished).
+			Where("post_id IN (?)", posts).
+			First(&comment).Error
+		if err != nil {
+			c.Error(apierror.NotFound("comment"))
+			return contentTarget{}, false
+		}
+		return contentTarget{
+			Type:      models.TargetComment,
+			ID:        comment.ID,
+			OwnerID:   comment.UserID,
+			Status:    comment.Status,
+			PostID:    comment.PostID,
+			CommentID: &comment.ID,
+		}, true
+	}
+
+	var post models.Post
+	if err := db.DB.Scopes(visibleTo(viewerID)).Where("id = ?", c.Param("post_id")).First(&post).Error; err != nil {
+		c.Error(apierror.NotFound("post"))
+		return contentTarget{}, false
+	}
+	return contentTarget{Type: models.TargetPost, ID: post.ID, OwnerID: post.UserID, Status: post.Status, PostID: post.ID}, true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"log/slog"
+	"slices"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/mentions"
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+)
+
+// syncMentions replaces the stored mentions of a post or a comment with the ones found
+// in text and notifies the users who are mentioned in it for the first time.
+func syncMentions(sourceType string, sourceID uint, text string, authorID uint, postID uint, commentID *uint) ([]models.Mention, error) {
+	var previous []models.Mention
+	if err := db.DB.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Find(&previous).Error; err != nil {
+		return nil, err
+	}
+
+	spans := mentions.Parse(text)
+	users := make(map[string]models.User)
+	if names := mentions.UserNames(spans); len(names) > 0 {
+		var found []models.User
+		if err := db.DB.Where("user_name IN ?", names).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range found {
+			users[user.UserName] = user
+		}
+
+		// users who blocked the author can not be mentioned by them
+		var blockers []uint
+		err := db.DB.Model(&models.Block{}).Where("blocked_id = ? AND user_id IN ?", authorID, userIDs(found)).Pluck("user_id", &blockers).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range found {
+			if slices.Contains(blockers, user.ID) {
+				delete(users, user.UserName)
+			}
+		}
+	}
+
+	current := []models.Mention{}
+	for _, span := range spans {
+		user, ok := users[span.UserName]
+		if !ok {
+			continue
+		}
+		current = append(current, models.Mention{
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			UserID:     user.ID,
+			UserName:   user.UserName,
+			Offset:     span.Offset,
+			Length:     span.Length,
+		})
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			return nil
+		}
+		return tx.Create(&current).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notified := make(map[uint]bool)
+	for _, mention := range previous {
+		notified[mention.UserID] = true
+	}
+	for _, mention := range current {
+		if notified[mention.UserID] {
+			continue
+		}
+		notified[mention.UserID] = true
+		notify(models.Notification{
+			UserID:    mention.UserID,
+			ActorID:   authorID,
+			Type:      models.NotificationMention,
+			PostID:    &postID,
+			CommentID: commentID,
+		})
+	}
+
+	return current, nil
+}
+
+// mentionsOf runs syncMentions for a handler, a failure is logged so the post or comment
+// that was already saved is still returned.
+func mentionsOf(sourceType string, sourceID uint, text string, authorID uint, postID uint, commentID *uint) []models.Mention {
+	found, err := syncMentions(sourceType, sourceID, text, authorID, postID, commentID)
+	if err != nil {
+		slog.Error("could not save mentions", "source_type", sourceType, "source_id", sourceID, "error", err)
+		return []models.Mention{}
+	}
+	return found
+}
+
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
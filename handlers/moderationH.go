@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/moderation"
+	"github.com/dayiamin/gin_blog_api/notifications"
+	"github.com/dayiamin/gin_blog_api/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSuspendDays is used when a suspend action does not say how long.
+const defaultSuspendDays = 7
+
+// @Summary List reports
+// @Description Moderation queue of reports, oldest first. report_count is the number of reports on the same content. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param status query string false "Report status: open (default), actioned, dismissed or all"
+// @Param target_type query string false "post or comment"
+// @Param reason query string false "Reason code"
+// @Param target_user_id query int false "Author of the reported content"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "reports: List of reports, count: Number of matching reports, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/reports [get]
+func ShowReports(c *gin.Context) {
+	query := db.DB.Model(&models.Report{})
+	switch status := c.DefaultQuery("status", models.ReportOpen); status {
+	case "all":
+	default:
+		query = query.Where("reports.status = ?", status)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("reports.target_type = ?", targetType)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reports.reason = ?", reason)
+	}
+	if targetUserID := c.Query("target_user_id"); targetUserID != "" {
+		query = query.Where("reports.target_user_id = ?", targetUserID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch reports").Wrap(err))
+		return
+	}
+
+	page, limit, offset := pagination(c)
+	reports := []models.Report{}
+	err := query.
+		Select("reports.*, (SELECT COUNT(*) FROM reports AS same WHERE same.target_type = reports.target_type AND same.target_id = reports.target_id) AS report_count").
+		Order("reports.created_at ASC").
+		Limit(limit).Offset(offset).
+		Find(&reports).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch reports").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports, "count": count, "page": page})
+}
+
+// @Summary Act on a report
+// @Description Resolve a report with an action on the reported content: hide it, delete it, warn its author, suspend its author for suspend_days (default 7) or dismiss the report. All open reports on the same content are resolved with it and the action is recorded. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param report_id path string true "Report ID"
+// @Param action body models.ModerationActionRegister true "Action to take"
+// @Success 200 {object} map[string]interface{} "message: Action applied, action: Recorded moderation action"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 404 {object} apierror.Error "Report not found"
+// @Failure 409 {object} apierror.Error "Report already resolved"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/reports/{report_id}/action [post]
+func TakeModerationAction(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	moderatorID := userIDVal.(uint)
+
+	var input models.ModerationActionRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	var report models.Report
+	if err := db.DB.Where("id = ?", c.Param("report_id")).First(&report).Error; err != nil {
+		c.Error(apierror.NotFound("report"))
+		return
+	}
+	if report.Status != models.ReportOpen {
+		c.Error(apierror.Conflict(apierror.CodeReportResolved, "report already resolved"))
+		return
+	}
+
+	before := contentSnapshot(report.TargetType, report.TargetID)
+	if err := applyModerationAction(report, input); err != nil {
+		c.Error(apierror.Internal("could not apply the action").Wrap(err))
+		return
+	}
+
+	resolution := models.ReportActioned
+	if input.Action == models.ModerationDismiss {
+		resolution = models.ReportDismissed
+	}
+	action := models.ModerationAction{
+		ModeratorID:  moderatorID,
+		Action:       input.Action,
+		TargetType:   report.TargetType,
+		TargetID:     report.TargetID,
+		TargetUserID: report.TargetUserID,
+		ReportID:     &report.ID,
+		Note:         input.Note,
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, models.ReportOpen).
+			Updates(map[string]any{"status": resolution, "resolved_by_id": moderatorID, "resolved_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&action).Error
+	})
+	if err != nil {
+		c.Error(apierror.Internal("could not resolve the report").Wrap(err))
+		return
+	}
+	audit.Record(c, audit.Entry{
+		Action:     audit.Moderation(input.Action),
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Before:     before,
+		After:      contentSnapshot(report.TargetType, report.TargetID),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "action applied", "action": action})
+}
+
+// applyModerationAction carries out a moderator's action on the content of a report.
+func applyModerationAction(report models.Report, input models.ModerationActionRegister) error {
+	switch input.Action {
+	case models.ModerationHide:
+		_, err := setContentStatus(db.DB, report.TargetType, report.TargetID, models.StatusHidden)
+		return err
+
+	case models.ModerationDelete:
+		if report.TargetType == models.TargetComment {
+			var comment models.PostComment
+			if err := db.DB.Where("id = ?", report.TargetID).Limit(1).Find(&comment).Error; err != nil || comment.ID == 0 {
+				return err
+			}
+			placeholder, err := repository.NewStore(db.DB).Comments().Delete(&comment)
+			if err == nil {
+				publishCommentDeleted(&comment, placeholder)
+			}
+			return err
+		}
+		var post models.Post
+		if err := db.DB.Where("id = ?", report.TargetID).Limit(1).Find(&post).Error; err != nil || post.ID == 0 {
+			return err
+		}
+		return repository.NewStore(db.DB).Posts().Trash(&post)
+
+	case models.ModerationWarn:
+		postID, commentID := reportedContent(report)
+		return notifications.Notify(models.Notification{
+			UserID:    report.TargetUserID,
+			Type:      models.NotificationWarning,
+			PostID:    postID,
+			CommentID: commentID,
+		})
+
+	case models.ModerationSuspend:
+		days := input.SuspendDays
+		if days == 0 {
+			days = defaultSuspendDays
+		}
+		reason := input.Note
+		if reason == "" {
+			reason = report.Reason
+		}
+		return db.DB.Model(&models.User{}).Where("id = ?", report.TargetUserID).Updates(map[string]any{
+			"suspended_until":   time.Now().AddDate(0, 0, days),
+			"suspension_reason": reason,
+		}).Error
+	}
+	return nil
+}
+
+// contentSnapshot loads a post or a comment, deleted ones included, for the audit log.
+func contentSnapshot(targetType string, targetID uint) any {
+	if targetType == models.TargetComment {
+		var comment models.PostComment
+		if db.DB.Unscoped().Where("id = ?", targetID).Limit(1).Find(&comment).Error != nil || comment.ID == 0 {
+			return nil
+		}
+		return comment
+	}
+
+	var post models.Post
+	if db.DB.Unscoped().Where("id = ?", targetID).Limit(1).Find(&post).Error != nil || post.ID == 0 {
+		return nil
+	}
+	return post
+}
+
+// reportedContent returns the post and, for a comment, the comment a report is about.
+func reportedContent(report models.Report) (*uint, *uint) {
+	if report.TargetType == models.TargetPost {
+		return &report.TargetID, nil
+	}
+
+	var comment models.PostComment
+	db.DB.Unscoped().Where("id = ?", report.TargetID).Limit(1).Find(&comment)
+	return &comment.PostID, &report.TargetID
+}
+
+// @Summary List moderation actions
+// @Description Audit trail of the actions taken by moderators and by auto hiding, newest first. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param target_type query string false "post or comment"
+// @Param target_id query int false "ID of the content"
+// @Param moderator_id query int false "Moderator who acted, 0 for automatic actions"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "actions: List of moderation actions, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/actions [get]
+func ShowModerationActions(c *gin.Context) {
+	query := db.DB.Model(&models.ModerationAction{})
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if moderatorID := c.Query("moderator_id"); moderatorID != "" {
+		query = query.Where("moderator_id = ?", moderatorID)
+	}
+
+	page, limit, offset := pagination(c)
+	actions := []models.ModerationAction{}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&actions).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch moderation actions").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"actions": actions, "page": page})
+}
+
+// @Summary List content waiting for review
+// @Description Posts and comments held back by the moderation filters, oldest first, with the reason they were held. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "pending: List of pending posts and comments, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/pending [get]
+func ShowPendingContent(c *gin.Context) {
+	page, limit, offset := pagination(c)
+
+	// the latest filter verdict on each item is its reason
+	reason := func(targetType, table string) string {
+		return "COALESCE((SELECT note FROM moderation_actions WHERE action = '" + models.ModerationFilter +
+			"' AND target_type = '" + targetType + "' AND target_id = " + table + ".id ORDER BY id DESC LIMIT 1), '')"
+	}
+
+	pending := []models.PendingContent{}
+	// the union is wrapped in a derived table so ORDER BY and LIMIT apply to it the same
+	// way on every database
+	err := db.DB.Raw(
+		"SELECT * FROM ("+
+			"SELECT 'post' AS target_type, posts.id AS target_id, posts.id AS post_id, posts.user_id, posts.title, posts.caption AS text, "+reason(models.TargetPost, "posts")+" AS reason, posts.created_at"+
+			" FROM posts WHERE posts.status = ? AND posts.deleted_at IS NULL"+
+			" UNION ALL "+
+			"SELECT 'comment', post_comments.id, post_comments.post_id, post_comments.user_id, '', post_comments.text, "+reason(models.TargetComment, "post_comments")+", post_comments.created_at"+
+			" FROM post_comments WHERE post_comments.status = ? AND post_comments.deleted_at IS NULL"+
+			") AS pending ORDER BY created_at LIMIT ? OFFSET ?",
+		models.StatusPending, models.StatusPending, limit, offset,
+	).Scan(&pending).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch pending content").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pending": pending, "page": page})
+}
+
+// @Summary Approve a pending post
+// @Description Publish a post held back by the moderation filters. Mentioned users are notified at this point. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param review body models.PendingReview false "Optional note"
+// @Success 200 {object} map[string]interface{} "message: Content published, action: Recorded moderation action"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 404 {object} apierror.Error "No pending content with this id"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/posts/{post_id}/approve [post]
+func ApproveContent(c *gin.Context) {
+	reviewPending(c, models.ModerationApprove, models.StatusPublished, nil)
+}
+
+// @Summary Reject pending content
+// @Description Keep a post or a comment held back by the moderation filters hidden for good. Requires the moderator or admin role.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string false "Post ID, for a post"
+// @Param comment_id path string false "Comment ID, for a comment"
+// @Param review body models.PendingReview false "Optional note"
+// @Success 200 {object} map[string]interface{} "message: Content rejected, action: Recorded moderation action"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing moderator role"
+// @Failure 404 {object} apierror.Error "No pending content with this id"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /moderation/posts/{post_id}/reject [post]
+// @Router /moderation/comments/{comment_id}/reject [post]
+func RejectContent(c *gin.Context) {
+	reviewPending(c, models.ModerationReject, models.StatusHidden, nil)
+}
+
+// reviewPending moves a pending post or comment to status and records the decision.
+// announce is called for an approved comment that was never published before.
+func reviewPending(c *gin.Context, action, status string, announce func(*models.PostComment)) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var input models.PendingReview
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBind(&input); err != nil {
+			c.Error(apierror.Invalid(err))
+			return
+		}
+	}
+
+	var post models.Post
+	var comment models.PostComment
+	record := models.ModerationAction{ModeratorID: userIDVal.(uint), Action: action, Note: input.Note}
+	if commentID := c.Param("comment_id"); commentID != "" {
+		if err := db.DB.Where("id = ? AND status = ?", commentID, models.StatusPending).First(&comment).Error; err != nil {
+			c.Error(apierror.NotFound("comment").WithDetail("no pending comment with this id"))
+			return
+		}
+		record.TargetType, record.TargetID, record.TargetUserID = models.TargetComment, comment.ID, comment.UserID
+	} else {
+		if err := db.DB.Where("id = ? AND status = ?", c.Param("post_id"), models.StatusPending).First(&post).Error; err != nil {
+			c.Error(apierror.NotFound("post").WithDetail("no pending post with this id"))
+			return
+		}
+		record.TargetType, record.TargetID, record.TargetUserID = models.TargetPost, post.ID, post.UserID
+	}
+
+	// an approved edit gets back the status the content had before it was held, only
+	// content that was never published is announced
+	heldFrom := post.HeldFrom
+	var model any = &post
+	if record.TargetType == models.TargetComment {
+		heldFrom, model = comment.HeldFrom, &comment
+	}
+	if status == models.StatusPublished && heldFrom != "" {
+		status = heldFrom
+	}
+
+	before := contentSnapshot(record.TargetType, record.TargetID)
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(model).Updates(map[string]any{"status": status, "held_from": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		c.Error(apierror.Internal("could not review the content").Wrap(err))
+		return
+	}
+	post.Status, post.HeldFrom = status, ""
+	comment.Status, comment.HeldFrom = status, ""
+	audit.Record(c, audit.Entry{
+		Action:     audit.Moderation(action),
+		TargetType: record.TargetType,
+		TargetID:   record.TargetID,
+		Before:     before,
+		After:      contentSnapshot(record.TargetType, record.TargetID),
+	})
+
+	switch {
+	case action != models.ModerationApprove:
+	case record.TargetType == models.TargetPost:
+		mentionsOf(models.TargetPost, post.ID, post.Caption, post.UserID, post.ID, nil)
+	case heldFrom == "" && announce != nil:
+		announce(&comment)
+	default:
+		// mentions added by the edit are notified, the comment itself was announced before
+		mentionsOf(models.TargetComment, comment.ID, comment.Text, comment.UserID, comment.PostID, &comment.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": record.TargetType + " " + status, "action": record})
+}
+
+// screen runs the moderation filters on new or edited content and returns the status
+// to save it with, and the verdict when a filter held it back. It records the
+// error itself.
+func screen(c *gin.Context, content moderation.Content) (string, *moderation.Verdict, bool) {
+	verdict, err := moderation.Default().Check(content)
+	if err != nil {
+		c.Error(apierror.Internal("could not check the content").Wrap(err))
+		return "", nil, false
+	}
+	if verdict != nil {
+		return models.StatusPending, verdict, true
+	}
+	return models.StatusPublished, nil, true
+}
+
+// holdForReview records why the filters sent a post or a comment to the pending queue.
+func holdForReview(targetType string, targetID, authorID uint, verdict *moderation.Verdict) {
+	err := db.DB.Create(&models.ModerationAction{
+		Action:       models.ModerationFilter,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		TargetUserID: authorID,
+		Note:         verdict.Filter + ": " + verdict.Reason,
+	}).Error
+	if err != nil {
+		slog.Error("could not record the filter verdict", "target_type", targetType, "target_id", targetID, "error", err)
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// @Summary List notifications
+// @Description Retrieve the authenticated user's notifications, newest first, together with the number of unread ones. Requires JWT authentication.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param unread query bool false "Only return unread notifications"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "notifications: List of notifications, unread_count: Number of unread notifications, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /notifications [get]
+func ShowNotifications(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var unreadCount int64
+	if err := db.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unreadCount).Error; err != nil {
+		c.Error(apierror.Internal("could not count notifications").Wrap(err))
+		return
+	}
+
+	page, limit, offset := pagination(c)
+	query := db.DB.Table("notifications").
+		Select("notifications.*, users.user_name AS actor_name").
+		Joins("LEFT JOIN users ON users.id = notifications.actor_id").
+		Where("notifications.user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("notifications.read_at IS NULL")
+	}
+
+	list := []models.Notification{}
+	if err := query.Order("notifications.id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch notifications").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": list, "unread_count": unreadCount, "page": page})
+}
+
+// @Summary Mark a notification as read
+// @Description Mark one of the authenticated user's notifications as read. Requires JWT authentication.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param notification_id path string true "Notification ID"
+// @Success 200 {object} map[string]string "message: Notification marked as read"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Notification not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /notifications/{notification_id}/read [post]
+func MarkNotificationRead(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var notification models.Notification
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("notification_id"), userID).First(&notification).Error; err != nil {
+		c.Error(apierror.NotFound("notification"))
+		return
+	}
+
+	if notification.ReadAt == nil {
+		if err := db.DB.Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+			c.Error(apierror.Internal("could not update the notification").Wrap(err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the authenticated user as read. Requires JWT authentication.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Success 200 {object} map[string]interface{} "message: Notifications marked as read, updated: Number of updated notifications"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /notifications/read-all [post]
+func MarkAllNotificationsRead(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	result := db.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+	if result.Error != nil {
+		c.Error(apierror.Internal("could not update the notifications").Wrap(result.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notifications marked as read", "updated": result.RowsAffected})
+}
+
+// @Summary Get notification preferences
+// @Description Retrieve which notification types the authenticated user receives. Requires JWT authentication.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Success 200 {object} map[string]map[string]bool "preferences: Enabled state per notification type"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /notifications/preferences [get]
+func ShowNotificationPreferences(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	preferences, err := notifications.Preferences(userIDVal.(uint))
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch preferences").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+// @Summary Update notification preferences
+// @Description Turn notification types (comment, reply, mention, follow, reaction) on or off for the authenticated user. Types that are not sent keep their current state. Requires JWT authentication.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param preferences body models.NotificationPreferencesUpdate true "Enabled state per notification type"
+// @Success 200 {object} map[string]map[string]bool "preferences: Enabled state per notification type"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Unknown notification type"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /notifications/preferences [put]
+func UpdateNotificationPreferences(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.NotificationPreferencesUpdate
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	var rows []models.NotificationPreference
+	for notificationType, enabled := range input {
+		if !slices.Contains(models.NotificationTypes, notificationType) {
+			c.Error(apierror.Validation(apierror.FieldError{Field: notificationType, Rule: "oneof", Message: "is not a notification type"}))
+			return
+		}
+		rows = append(rows, models.NotificationPreference{UserID: userID, Type: notificationType, Enabled: enabled})
+	}
+
+	if len(rows) > 0 {
+		err := db.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+		}).Create(&rows).Error
+		if err != nil {
+			c.Error(apierror.Internal("could not save preferences").Wrap(err))
+			return
+		}
+	}
+
+	preferences, err := notifications.Preferences(userID)
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch preferences").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+// notify sends a notification on behalf of a handler, a failure is logged instead of
+// failing the request that caused it.
+func notify(notification models.Notification) {
+	if err := notifications.Notify(notification); err != nil {
+		slog.Error("could not notify a user", "recipient_id", notification.UserID, "error", err)
+	}
+}
@@ -1,27 +1,51 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
-	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
 	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/moderation"
+	"github.com/dayiamin/gin_blog_api/services"
 	"github.com/gin-gonic/gin"
 )
 
+// PostHandler serves the post endpoints on top of a PostService.
+type PostHandler struct {
+	posts *services.PostService
+}
+
+func NewPostHandler(posts *services.PostService) *PostHandler {
+	return &PostHandler{posts: posts}
+}
+
 // @Summary Get all posts
-// @Description Retrieve a list of all posts with their associated comments.
+// @Description Retrieve a list of all posts with their associated comments and reaction counts. When a JWT is sent, my_reaction holds the user's own reaction and content of blocked and muted users is left out.
 // @Tags posts
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only the posts filed under this tag"
 // @Success 200 {object} map[string][]models.Post "posts: List of posts with comments"
-// @Failure 500 {object} map[string]string "error: Failed to fetch posts"
+// @Failure 500 {object} apierror.Error "Failed to fetch posts"
 // @Router /post [get]
-func ShowPosts(c *gin.Context) {
-
-	var posts []models.Post
-
-	if err := db.DB.Preload("PostComment").Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+func (h *PostHandler) ShowPosts(c *gin.Context) {
+	viewerID := currentUserID(c)
+	posts, err := h.posts.List(viewerID, c.Query("tag"))
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch posts").Wrap(err))
+		return
+	}
+	if err := attachPostReactions(posts, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
+	}
+	if err := attachBookmarks(posts, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
 		return
 	}
 
@@ -29,159 +53,216 @@ func ShowPosts(c *gin.Context) {
 
 }
 
-// @Summary Create a new post
-// @Description Create a new post with image address, title, and caption for the authenticated user. Requires JWT authentication.
+// @Summary Get a post
+// @Description Retrieve one post with its comments and reaction counts. Each request counts as a view for the author's analytics, repeated views by the same visitor within a short window and views by bots are not counted.
 // @Tags posts
 // @Accept json
 // @Produce json
-// @Security JWT
-// @Param post body models.PostRegister true "Post creation details"
-// @Success 201 {object} map[string]interface{} "message: Post created, post: Created post data"
-// @Failure 400 {object} map[string]string "error: Invalid input"
-// @Failure 401 {object} map[string]string "error: Unauthorized (missing or invalid JWT)"
-// @Failure 500 {object} map[string]string "error: Internal server error (database issue)"
-// @Router /post/register [post]
-func RegisterPost(c *gin.Context) {
-	userIDVal, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// @Param post_id path string true "Post ID"
+// @Success 200 {object} map[string]models.Post "post: The post with comments"
+// @Failure 404 {object} apierror.Error "Post not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id} [get]
+func (h *PostHandler) ShowPost(c *gin.Context) {
+	viewerID := currentUserID(c)
+
+	post, err := h.posts.Get(viewerID, idParam(c, "post_id"))
+	if errors.Is(err, services.ErrNotFound) {
+		c.Error(apierror.NotFound("post"))
 		return
 	}
-
-	var input models.PostRegister
-	if err := c.Bind(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch the post").Wrap(err))
 		return
 	}
-	userID := userIDVal.(uint)
 
-	post := models.Post{
-		PicAddres: input.PicAddres,
-		Title:     input.Title,
-		Caption:   input.Caption,
-		UserID:    userID,
+	posts := []models.Post{post}
+	if err := attachPostReactions(posts, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
 	}
-
-	if err := db.DB.Create(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create post"})
+	if err := attachBookmarks(posts, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "post created", "post": post})
+	if viewerID != post.UserID {
+		analytics.RecordView(analytics.View{
+			PostID:    post.ID,
+			UserID:    viewerID,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Referrer:  c.Request.Referer(),
+			At:        time.Now(),
+		})
+	}
 
+	c.JSON(http.StatusOK, gin.H{"post": posts[0]})
 }
 
-// @Summary Delete a post
-// @Description Delete a post and its associated comments by post ID. Requires JWT authentication.
+// @Summary Create a new post
+// @Description Create a new post with image address, title, caption and tags for the authenticated user. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Security JWT
-// @Param post_id path string true "Post ID"
-// @Success 200 {object} map[string]string "message: Post deleted, Post ID: Deleted post ID"
-// @Failure 400 {object} map[string]string "error: Failed to find the post"
-// @Failure 401 {object} map[string]string "error: Unauthorized (missing or invalid JWT)"
-// @Failure 500 {object} map[string]string "error: Internal server error (database issue)"
-// @Router /post/{post_id} [delete]
-func DeletePost(c *gin.Context) {
-	postID := c.Param("post_id")
+// @Param post body models.PostRegister true "Post creation details"
+// @Success 201 {object} map[string]interface{} "message: Post created, post: Created post data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/register [post]
+func (h *PostHandler) RegisterPost(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var input models.PostRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	userID := userIDVal.(uint)
 
-	var postDB models.Post
-	if err := db.DB.Where("id =?", postID).First(&postDB).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find the post"})
+	status, verdict, ok := screen(c, moderation.Content{Type: models.TargetPost, AuthorID: userID, Title: input.Title, Text: input.Caption})
+	if !ok {
 		return
 	}
 
-	if err := db.DB.Preload("PostComment").Delete(&postDB).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to Delete the post"})
+	post, err := h.posts.Create(userID, input, status)
+	switch {
+	case errors.Is(err, services.ErrTooManyTags):
+		c.Error(tooManyTags())
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not create post").Wrap(err))
 		return
 	}
-	var postComments models.PostComment
-	if err := db.DB.Where("post_id=?",postID).Delete(&postComments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find the comments"})
+	if verdict != nil {
+		holdForReview(models.TargetPost, post.ID, userID, verdict)
+		c.JSON(http.StatusCreated, gin.H{"message": "post is waiting for moderator review", "post": post})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "post Deleted", "Post ID": postID})
+	post.Mentions = mentionsOf(models.TargetPost, post.ID, post.Caption, userID, post.ID, nil)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "post created", "post": post})
 
 }
 
-// @Summary Create a comment
-// @Description Add a comment to a specific post for the authenticated user. Requires JWT authentication.
-// @Tags comments
+// @Summary Update a post
+// @Description Update the image address, title, caption or tags of a post owned by the authenticated user. Empty fields keep their current value, tags are replaced when sent. Mentions in the caption are parsed again and newly mentioned users are notified. Content flagged by the moderation filters is saved as pending and only shown to its author until a moderator approves it. Requires JWT authentication.
+// @Tags posts
 // @Accept json
 // @Produce json
 // @Security JWT
 // @Param post_id path string true "Post ID"
-// @Param comment body models.PostCommentsRegister true "Comment details"
-// @Success 201 {object} map[string]interface{} "message: Comment added successfully, comment: Created comment data"
-// @Failure 400 {object} map[string]string "error: Invalid input"
-// @Failure 401 {object} map[string]string "error: Unauthorized (missing or invalid JWT)"
-// @Failure 500 {object} map[string]string "error: Internal server error (post not found or database issue)"
-// @Router /post/{post_id}/comments [post]
-func RegisterComment(c *gin.Context) {
+// @Param post body models.PostRegister true "Post fields to update"
+// @Success 200 {object} map[string]interface{} "message: Post updated, post: Updated post data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The post belongs to another user"
+// @Failure 404 {object} apierror.Error "Post not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id} [put]
+func (h *PostHandler) UpdatePost(c *gin.Context) {
 	userIDVal, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
 		return
 	}
 	userID := userIDVal.(uint)
-	postID := c.Param("post_id")
 
+	var input models.PostRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
 
-	var input models.PostCommentsRegister
-	if err := c.Bind(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	post, err := h.posts.Edit(userID, idParam(c, "post_id"), input)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("post"))
+		return
+	case errors.Is(err, services.ErrForbidden):
+		c.Error(apierror.Forbidden(apierror.CodeNotOwner, "you can only update your own posts"))
+		return
+	case errors.Is(err, services.ErrTooManyTags):
+		c.Error(tooManyTags())
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not update post").Wrap(err))
 		return
 	}
 
-	var post models.Post
-	if err := db.DB.Where("id=?", postID).First(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "post not found"})
+	_, verdict, ok := screen(c, moderation.Content{Type: models.TargetPost, ID: post.ID, AuthorID: userID, Title: post.Title, Text: post.Caption})
+	if !ok {
 		return
 	}
-	comment := models.PostComment{
-		Text:   input.Text,
-		UserID: userID,
-		PostID: post.ID,
+	if verdict != nil {
+		post.Hold()
 	}
 
-	if err := db.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+	if err := h.posts.Save(&post, input.Tags); err != nil {
+		c.Error(apierror.Internal("could not update post").Wrap(err))
 		return
 	}
+	if verdict != nil {
+		holdForReview(models.TargetPost, post.ID, userID, verdict)
+		c.JSON(http.StatusOK, gin.H{"message": "post is waiting for moderator review", "post": post})
+		return
+	}
+	post.Mentions = mentionsOf(models.TargetPost, post.ID, post.Caption, userID, post.ID, nil)
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Comment added successfully",
-		"comment": comment,
-	})
+	c.JSON(http.StatusOK, gin.H{"message": "post updated", "post": post})
 }
 
-// @Summary Delete a comment
-// @Description Delete a comment by its ID for a specific post. Requires JWT authentication.
-// @Tags comments
+// @Summary Delete a post
+// @Description Move a post of the authenticated user and its comments to the trash by post ID. Requires JWT authentication.
+// @Tags posts
 // @Accept json
 // @Produce json
 // @Security JWT
 // @Param post_id path string true "Post ID"
-/// @Param comment_id path string true "Comment ID"
-// @Success 200 {object} map[string]string "message: Comment deleted, comment ID: Deleted comment ID"
-// @Failure 400 {object} map[string]string "error: Failed to find the comment"
-// @Failure 401 {object} map[string]string "error: Unauthorized (missing or invalid JWT)"
-// @Failure 500 {object} map[string]string "error: Internal server error (database issue)"
-// @Router /post/{post_id}/comments/{comment_id} [delete]
-func DeleteComment(c *gin.Context) {
-
-	commentID := c.Param("comment_id")
-
-	var commentDB models.PostComment
-	if err := db.DB.Where("id =?", commentID).First(&commentDB).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find the comment"})
+// @Success 200 {object} map[string]string "message: Post deleted, Post ID: Deleted post ID"
+// @Failure 404 {object} apierror.Error "Post not found"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The post belongs to another user"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id} [delete]
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
 		return
 	}
+	postID := c.Param("post_id")
 
-	if err := db.DB.Delete(&commentDB).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to Delete the Comment"})
+	postDB, err := h.posts.Delete(userIDVal.(uint), idParam(c, "post_id"))
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("post"))
+		return
+	case errors.Is(err, services.ErrForbidden):
+		c.Error(apierror.Forbidden(apierror.CodeNotOwner, "you can only delete your own posts"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not delete the post").Wrap(err))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "comment Deleted", "comment ID": commentID})
+	audit.Record(c, audit.Entry{Action: audit.PostDelete, TargetType: models.TargetPost, TargetID: postDB.ID, Before: postDB})
+	c.JSON(http.StatusOK, gin.H{"message": "post Deleted", "Post ID": postID})
+
+}
+
+// tooManyTags is the problem of a post sent with more than models.MaxPostTags tags.
+func tooManyTags() *apierror.Error {
+	return apierror.Validation(apierror.FieldError{
+		Field:   "tags",
+		Rule:    "max",
+		Message: fmt.Sprintf("must have at most %d items", models.MaxPostTags),
+	})
 }
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// @Summary React to a post or a comment
+// @Description Set the authenticated user's reaction on a post or a comment visible to them, comments must be published on a published post. A user holds one reaction per target, sending another type replaces it and sending the same type again changes nothing. Requires JWT authentication.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string false "Comment ID, only for reactions on a comment"
+// @Param reaction body models.ReactionRegister true "Reaction type (like, love, laugh, wow, sad, angry)"
+// @Success 200 {object} map[string]interface{} "message: Reaction saved, reaction: Reaction data, reactions: Counts per reaction type"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Post or comment not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/reactions [put]
+// @Router /post/{post_id}/comments/{comment_id}/reactions [put]
+func SetReaction(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.ReactionRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	target, ok := findContentTarget(c)
+	if !ok {
+		return
+	}
+
+	var existing int64
+	if err := db.DB.Model(&models.Reaction{}).Where("user_id = ? AND target_type = ? AND target_id = ?", userID, target.Type, target.ID).Count(&existing).Error; err != nil {
+		c.Error(apierror.Internal("could not save reaction").Wrap(err))
+		return
+	}
+
+	reaction := models.Reaction{
+		UserID:     userID,
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		Type:       input.Type,
+	}
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "target_type"}, {Name: "target_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "updated_at"}),
+	}).Create(&reaction).Error
+	if err == nil {
+		// reload, on conflict the row keeps its original id and created_at
+		err = db.DB.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, target.Type, target.ID).First(&reaction).Error
+	}
+	if err != nil {
+		c.Error(apierror.Internal("could not save reaction").Wrap(err))
+		return
+	}
+
+	// changing the type of an existing reaction does not notify again
+	if existing == 0 {
+		notify(models.Notification{
+			UserID:    target.OwnerID,
+			ActorID:   userID,
+			Type:      models.NotificationReaction,
+			PostID:    &target.PostID,
+			CommentID: target.CommentID,
+		})
+	}
+
+	counts, _, err := reactionSummary(target.Type, []uint{target.ID}, 0)
+	if err != nil {
+		c.Error(apierror.Internal("could not count reactions").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reaction saved", "reaction": reaction, "reactions": counts[target.ID]})
+}
+
+// @Summary Remove a reaction from a post or a comment
+// @Description Remove the authenticated user's reaction from a post or a comment. Removing a reaction that does not exist succeeds as well. Requires JWT authentication.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string false "Comment ID, only for reactions on a comment"
+// @Success 200 {object} map[string]interface{} "message: Reaction removed, reactions: Counts per reaction type"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Post or comment not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/reactions [delete]
+// @Router /post/{post_id}/comments/{comment_id}/reactions [delete]
+func RemoveReaction(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	target, ok := findContentTarget(c)
+	if !ok {
+		return
+	}
+
+	err := db.DB.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, target.Type, target.ID).
+		Delete(&models.Reaction{}).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not remove reaction").Wrap(err))
+		return
+	}
+
+	counts, _, err := reactionSummary(target.Type, []uint{target.ID}, 0)
+	if err != nil {
+		c.Error(apierror.Internal("could not count reactions").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reaction removed", "reactions": counts[target.ID]})
+}
+
+// @Summary List reactions on a post or a comment
+// @Description Retrieve the users who reacted on a post or a comment, optionally filtered by reaction type. Pending posts are only found with the JWT of their author, hidden content and content of users who blocked the viewer is not found.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string false "Comment ID, only for reactions on a comment"
+// @Param type query string false "Reaction type filter"
+// @Success 200 {object} map[string][]models.Reactor "reactions: Users who reacted and their reaction"
+// @Failure 404 {object} apierror.Error "Post or comment not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/reactions [get]
+// @Router /post/{post_id}/comments/{comment_id}/reactions [get]
+func ShowReactions(c *gin.Context) {
+	target, ok := findContentTarget(c)
+	if !ok {
+		return
+	}
+
+	query := db.DB.Table("reactions").
+		Select("reactions.user_id, users.user_name, reactions.type, reactions.created_at").
+		Joins("JOIN users ON users.id = reactions.user_id").
+		Where("reactions.target_type = ? AND reactions.target_id = ?", target.Type, target.ID)
+	if reactionType := c.Query("type"); reactionType != "" {
+		query = query.Where("reactions.type = ?", reactionType)
+	}
+
+	reactors := []models.Reactor{}
+	if err := query.Order("reactions.created_at DESC").Scan(&reactors).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reactions": reactors})
+}
+
+// reactionSummary counts the reactions per type for every target id and, when viewerID
+// is set, returns the viewer's own reaction per target.
+func reactionSummary(targetType string, targetIDs []uint, viewerID uint) (map[uint]map[string]int64, map[uint]string, error) {
+	counts := make(map[uint]map[string]int64, len(targetIDs))
+	mine := make(map[uint]string)
+	for _, id := range targetIDs {
+		counts[id] = map[string]int64{}
+	}
+	if len(targetIDs) == 0 {
+		return counts, mine, nil
+	}
+
+	var rows []struct {
+		TargetID uint
+		Type     string
+		Total    int64
+	}
+	err := db.DB.Model(&models.Reaction{}).
+		Select("target_id, type, COUNT(*) AS total").
+		Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Group("target_id, type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, row := range rows {
+		counts[row.TargetID][row.Type] = row.Total
+	}
+
+	if viewerID != 0 {
+		var own []models.Reaction
+		err := db.DB.Where("user_id = ? AND target_type = ? AND target_id IN ?", viewerID, targetType, targetIDs).
+			Find(&own).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, reaction := range own {
+			mine[reaction.TargetID] = reaction.Type
+		}
+	}
+
+	return counts, mine, nil
+}
+
+// attachPostReactions fills the reaction counts and the viewer's reaction of the posts
+// and of their preloaded comments.
+func attachPostReactions(posts []models.Post, viewerID uint) error {
+	postIDs := make([]uint, 0, len(posts))
+	var comments []*models.PostComment
+	for i := range posts {
+		postIDs = append(postIDs, posts[i].ID)
+		for j := range posts[i].PostComment {
+			comments = append(comments, &posts[i].PostComment[j])
+		}
+	}
+
+	counts, mine, err := reactionSummary(models.TargetPost, postIDs, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Reactions = counts[posts[i].ID]
+		posts[i].MyReaction = mine[posts[i].ID]
+	}
+
+	return attachCommentReactions(comments, viewerID)
+}
+
+// attachCommentReactions fills the reaction counts and the viewer's reaction of the comments.
+func attachCommentReactions(comments []*models.PostComment, viewerID uint) error {
+	commentIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+	}
+
+	counts, mine, err := reactionSummary(models.TargetComment, commentIDs, viewerID)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.Reactions = counts[comment.ID]
+		comment.MyReaction = mine[comment.ID]
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// autoHideThreshold is the number of open reports from distinct users after which a
+// post or comment is hidden until a moderator looks at it, 0 turns auto hiding off.
+func autoHideThreshold() int {
+	return settings.Moderation.ReportAutoHideThreshold
+}
+
+// @Summary Report a post or a comment
+// @Description Flag a post or a comment visible to the user for the moderators with a reason code (spam, harassment, hate, violence, nudity, misinformation, other). A user can report the same content once. Content reported by enough distinct users is hidden automatically. Requires JWT authentication.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param comment_id path string false "Comment ID, only for reporting a comment"
+// @Param report body models.ReportRegister true "Report reason and note"
+// @Success 201 {object} map[string]interface{} "message: Report received, report: Report data"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields or reporting own content"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Post or comment not found"
+// @Failure 409 {object} apierror.Error "Content already reported by the user"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/report [post]
+// @Router /post/{post_id}/comments/{comment_id}/report [post]
+func ReportContent(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var input models.ReportRegister
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	target, ok := findContentTarget(c)
+	if !ok {
+		return
+	}
+	if target.OwnerID == userID {
+		c.Error(apierror.Unprocessable(apierror.CodeSelfAction, "you can not report your own content"))
+		return
+	}
+
+	report := models.Report{
+		ReporterID:   userID,
+		TargetType:   target.Type,
+		TargetID:     target.ID,
+		TargetUserID: target.OwnerID,
+		Reason:       input.Reason,
+		Note:         input.Note,
+		Status:       models.ReportOpen,
+	}
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+	if result.Error != nil {
+		c.Error(apierror.Internal("could not save the report").Wrap(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apierror.Conflict(apierror.CodeAlreadyReported, "you already reported this"))
+		return
+	}
+
+	// pending and hidden content already waits for a moderator
+	if target.Status == models.StatusPublished {
+		if err := autoHide(target); err != nil {
+			logging.FromContext(c.Request.Context()).Error("could not auto hide a reported target", "target_type", target.Type, "target_id", target.ID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "report received", "report": report})
+}
+
+// autoHide hides the target once it has autoHideThreshold open reports and records
+// the action for the moderators.
+func autoHide(target contentTarget) error {
+	threshold := autoHideThreshold()
+	if threshold <= 0 {
+		return nil
+	}
+
+	var reports int64
+	err := db.DB.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", target.Type, target.ID, models.ReportOpen).
+		Count(&reports).Error
+	if err != nil || reports < int64(threshold) {
+		return err
+	}
+
+	before := contentSnapshot(target.Type, target.ID)
+	hidden, err := setContentStatus(db.DB, target.Type, target.ID, models.StatusHidden)
+	if err != nil || !hidden {
+		return err
+	}
+	audit.Record(nil, audit.Entry{
+		Action:     audit.Moderation(models.ModerationAutoHide),
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		Before:     before,
+		After:      contentSnapshot(target.Type, target.ID),
+	})
+
+	return db.DB.Create(&models.ModerationAction{
+		Action:       models.ModerationAutoHide,
+		TargetType:   target.Type,
+		TargetID:     target.ID,
+		TargetUserID: target.OwnerID,
+		Note:         "hidden after reports from distinct users",
+	}).Error
+}
+
+// setContentStatus changes the status of a post or a comment using tx and reports
+// whether it was changed.
+func setContentStatus(tx *gorm.DB, targetType string, targetID uint, status string) (bool, error) {
+	var model any = &models.Post{}
+	if targetType == models.TargetComment {
+		model = &models.PostComment{}
+	}
+
+	result := tx.Model(model).Where("id = ? AND status <> ?", targetID, status).Update("status", status)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/dayiamin/gin_blog_api/repository"
+	"gorm.io/gorm"
+)
+
+// visibleTo is the repository.VisibleTo scope for the handlers of reactions, reports,
+// bookmarks, feeds and the other features outside the post, comment and user services,
+// which query db.DB directly.
+func visibleTo(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return repository.VisibleTo(viewerID)
+}
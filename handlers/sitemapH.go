@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/feeds"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/sitemap"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get the sitemap
+// @Description Retrieve the XML sitemap of the published posts and the profiles of their authors. Sites with more than 50000 addresses get a sitemap index pointing to /sitemap/{page}.xml instead. Supports conditional requests with If-None-Match and If-Modified-Since.
+// @Tags feeds
+// @Produce xml
+// @Success 200 {string} string "The sitemap or sitemap index"
+// @Success 304 {string} string "The sitemap did not change"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /sitemap.xml [get]
+func ShowSitemap(c *gin.Context) {
+	pages, err := sitemap.Pages(sitemapURLs)
+	if err != nil {
+		c.Error(apierror.Internal("could not build the sitemap").Wrap(err))
+		return
+	}
+	if len(pages) == 1 {
+		serveConditional(c, sitemap.ContentType, pages[0].Body, pages[0].LastMod)
+		return
+	}
+
+	// the pages are addressed from SITE_URL like the entries, never from the Host
+	// header a client can forge
+	body, err := sitemap.Index(feeds.SiteURL(), pages)
+	if err != nil {
+		c.Error(apierror.Internal("could not build the sitemap").Wrap(err))
+		return
+	}
+	var modified time.Time
+	for _, page := range pages {
+		if page.LastMod.After(modified) {
+			modified = page.LastMod
+		}
+	}
+	serveConditional(c, sitemap.ContentType, body, modified)
+}
+
+// @Summary Get a page of the sitemap
+// @Description Retrieve one of the sitemaps listed by the sitemap index. Supports conditional requests with If-None-Match and If-Modified-Since.
+// @Tags feeds
+// @Produce xml
+// @Param page path string true "Page number followed by .xml, like 1.xml"
+// @Success 200 {string} string "The sitemap"
+// @Success 304 {string} string "The sitemap did not change"
+// @Failure 404 {object} apierror.Error "Sitemap not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /sitemap/{page} [get]
+func ShowSitemapPage(c *gin.Context) {
+	number, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+	if err != nil || !strings.HasSuffix(c.Param("page"), ".xml") {
+		c.Error(apierror.NotFound("sitemap"))
+		return
+	}
+
+	pages, err := sitemap.Pages(sitemapURLs)
+	if err != nil {
+		c.Error(apierror.Internal("could not build the sitemap").Wrap(err))
+		return
+	}
+	if number < 1 || number > len(pages) {
+		c.Error(apierror.NotFound("sitemap"))
+		return
+	}
+	serveConditional(c, sitemap.ContentType, pages[number-1].Body, pages[number-1].LastMod)
+}
+
+// sitemapURLs lists the public posts and the profiles of the users who wrote them.
+func sitemapURLs() ([]sitemap.URL, error) {
+	var posts []models.Post
+	err := db.DB.Scopes(visibleTo(0)).Select("id", "updated_at", "user_id").Order("id").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var authors []models.User
+	err = db.DB.Select("id", "user_name", "updated_at").
+		Where("id IN (?)", db.DB.Model(&models.Post{}).Scopes(visibleTo(0)).Distinct("user_id")).
+		Order("id").Find(&authors).Error
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, len(posts)+len(authors))
+	for _, post := range posts {
+		urls = append(urls, sitemap.URL{Loc: feeds.PostURL(post.ID), LastMod: post.UpdatedAt})
+	}
+	for _, author := range authors {
+		urls = append(urls, sitemap.URL{Loc: feeds.AuthorURL(author.UserName), LastMod: author.UpdatedAt})
+	}
+	return urls, nil
+}
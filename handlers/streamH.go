@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/stream"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CommentCreatedEvent = "comment.created"
+	CommentDeletedEvent = "comment.deleted"
+)
+
+// @Summary Stream comments of a post
+// @Description Server-Sent Events stream of comment.created and comment.deleted events of a post visible to the authenticated user. Browsers can pass the JWT in the access_token query parameter. Send Last-Event-ID (or last_event_id) to receive the recent events missed since that id. Requires JWT authentication.
+// @Tags comments
+// @Produce text/event-stream
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Param access_token query string false "JWT, for clients that can not set the Authorization header"
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 404 {object} apierror.Error "Post not found"
+// @Router /post/{post_id}/comments/stream [get]
+func StreamComments(c *gin.Context) {
+	var post models.Post
+	if err := db.DB.Scopes(visibleTo(currentUserID(c))).Where("id = ?", c.Param("post_id")).First(&post).Error; err != nil {
+		c.Error(apierror.NotFound("post"))
+		return
+	}
+
+	serveStream(c, stream.PostTopic(post.ID))
+}
+
+// @Summary Stream notifications
+// @Description Server-Sent Events stream of the authenticated user's new notifications. Browsers can pass the JWT in the access_token query parameter. Send Last-Event-ID (or last_event_id) to receive the recent events missed since that id. Requires JWT authentication.
+// @Tags notifications
+// @Produce text/event-stream
+// @Security JWT
+// @Param access_token query string false "JWT, for clients that can not set the Authorization header"
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Router /notifications/stream [get]
+func StreamNotifications(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	serveStream(c, stream.UserTopic(userIDVal.(uint)))
+}
+
+// serveStream writes the events of a topic as Server-Sent Events until the client
+// disconnects, with a comment line every configured heartbeat to keep proxies from
+// closing an idle connection.
+func serveStream(c *gin.Context, topic string) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	replay, events, cancel := stream.Default.Subscribe(topic, lastID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range replay {
+		writeEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(settings.Stream.Heartbeat.Duration)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// the hub dropped a subscriber that fell behind, the client resumes with Last-Event-ID
+				return
+			}
+			writeEvent(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, event stream.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Type,
+		Data:  event.Data,
+	})
+}
+
+// publishCommentEvent sends a comment event to the clients streaming the post.
+func publishCommentEvent(eventType string, postID uint, data any) {
+	stream.Default.Publish(stream.PostTopic(postID), eventType, data)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/feeds"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// feedSize is how many of the newest posts a feed holds.
+func feedSize() int {
+	return settings.Site.FeedSize
+}
+
+// @Summary Get the site feed
+// @Description Retrieve the newest published posts as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.
+// @Tags feeds
+// @Produce application/rss+xml
+// @Produce application/atom+xml
+// @Produce application/feed+json
+// @Success 200 {string} string "The feed document"
+// @Success 304 {string} string "The feed did not change"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /feed.rss [get]
+// @Router /feed.atom [get]
+// @Router /feed.json [get]
+func ShowSiteFeed(c *gin.Context) {
+	serveFeed(c, "Gin Blog", "The newest posts", feeds.SiteURL()+"/post", db.DB)
+}
+
+// @Summary Get the feed of a user
+// @Description Retrieve the newest published posts of a user as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.
+// @Tags feeds
+// @Produce application/rss+xml
+// @Produce application/atom+xml
+// @Produce application/feed+json
+// @Param user_name path string true "Username"
+// @Success 200 {string} string "The feed document"
+// @Success 304 {string} string "The feed did not change"
+// @Failure 404 {object} apierror.Error "User not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/{user_name}/feed.rss [get]
+// @Router /user/{user_name}/feed.atom [get]
+// @Router /user/{user_name}/feed.json [get]
+func ShowAuthorFeed(c *gin.Context) {
+	var user models.User
+	if err := db.DB.Where("user_name = ?", c.Param("user_name")).First(&user).Error; err != nil {
+		c.Error(apierror.NotFound("user"))
+		return
+	}
+	serveFeed(c, "Posts by "+user.UserName, "The newest posts by "+user.UserName, feeds.AuthorURL(user.UserName),
+		db.DB.Where("user_id = ?", user.ID))
+}
+
+// @Summary Get the feed of a tag
+// @Description Retrieve the newest published posts filed under a tag as an RSS 2.0, Atom or JSON Feed document, picked by the extension of the path. Supports conditional requests with If-None-Match and If-Modified-Since.
+// @Tags feeds
+// @Produce application/rss+xml
+// @Produce application/atom+xml
+// @Produce application/feed+json
+// @Param tag path string true "Tag name"
+// @Success 200 {string} string "The feed document"
+// @Success 304 {string} string "The feed did not change"
+// @Failure 404 {object} apierror.Error "Tag not found"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /tag/{tag}/feed.rss [get]
+// @Router /tag/{tag}/feed.atom [get]
+// @Router /tag/{tag}/feed.json [get]
+func ShowTagFeed(c *gin.Context) {
+	var tag models.Tag
+	if err := db.DB.Where("name = ?", models.TagName(c.Param("tag"))).First(&tag).Error; err != nil {
+		c.Error(apierror.NotFound("tag"))
+		return
+	}
+	serveFeed(c, "Posts tagged "+tag.Name, "The newest posts tagged "+tag.Name, feeds.SiteURL()+"/post?tag="+tag.Name,
+		db.DB.Where("id IN (?)", repository.TaggedWith(db.DB, tag.Name)))
+}
+
+// serveFeed renders the newest public posts matching query in the format of the
+// requested path's extension.
+func serveFeed(c *gin.Context, title, description, link string, query *gorm.DB) {
+	var posts []models.Post
+	err := query.Scopes(visibleTo(0)).
+		Preload("Tags").
+		Order("created_at DESC").Limit(feedSize()).
+		Find(&posts).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch posts").Wrap(err))
+		return
+	}
+
+	userIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		userIDs = append(userIDs, post.UserID)
+	}
+	var users []models.User
+	if err := db.DB.Select("id", "user_name").Where("id IN ?", append(userIDs, 0)).Find(&users).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch posts").Wrap(err))
+		return
+	}
+	authors := make(map[uint]string, len(users))
+	for _, user := range users {
+		authors[user.ID] = user.UserName
+	}
+
+	self := feeds.SiteURL() + strings.TrimPrefix(c.Request.URL.Path, "/api/v1")
+	feed := feeds.New(title, description, link, self, posts, authors)
+
+	var body []byte
+	var contentType string
+	switch path.Ext(c.FullPath()) {
+	case ".rss":
+		body, err = feeds.RSS(feed)
+		contentType = feeds.RSSType
+	case ".atom":
+		body, err = feeds.Atom(feed)
+		contentType = feeds.AtomType
+	default:
+		body, err = feeds.JSON(feed)
+		contentType = feeds.JSONType
+	}
+	if err != nil {
+		c.Error(apierror.Internal("could not render the feed").Wrap(err))
+		return
+	}
+	serveConditional(c, contentType, body, feed.Updated)
+}
+
+// serveConditional writes body with an ETag and, when modified is set, a Last-Modified
+// header, or answers 304 Not Modified when the client's copy is still current.
+func serveConditional(c *gin.Context, contentType string, body []byte, modified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !modified.IsZero() {
+		if !modified.Truncate(time.Second).After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/jobs"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @Summary List your deleted posts
+// @Description Retrieve the authenticated user's deleted posts, newest deletion first. They can be restored until the retention period (retention_hours) ends and they are purged for good. Requires JWT authentication.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "posts: Deleted posts, retention_hours: Hours a deleted post stays restorable, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/trash [get]
+func ShowTrash(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	showTrash(c, db.DB.Where("user_id = ?", userIDVal.(uint)))
+}
+
+// @Summary List all deleted posts
+// @Description Retrieve the deleted posts of every user, or of one user with user_name, newest deletion first. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param user_name query string false "Only the posts of this user"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "posts: Deleted posts, retention_hours: Hours a deleted post stays restorable, page: Current page"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "Missing admin role"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /admin/trash [get]
+func ShowAllTrash(c *gin.Context) {
+	query := db.DB
+	if userName := c.Query("user_name"); userName != "" {
+		query = query.Where("user_id IN (?)", db.DB.Model(&models.User{}).Select("id").Where("user_name = ?", userName))
+	}
+	showTrash(c, query)
+}
+
+func showTrash(c *gin.Context, query *gorm.DB) {
+	page, limit, offset := pagination(c)
+	posts := []models.Post{}
+	err := query.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch the trash").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts, "retention_hours": int(jobs.TrashRetention().Hours()), "page": page})
+}
+
+// @Summary Restore a deleted post
+// @Description Bring a deleted post back from the trash together with the comments deleted along with it. Authors can restore their own posts unless a moderator deleted them, admins can restore any post. Requires JWT authentication.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param post_id path string true "Post ID"
+// @Success 200 {object} map[string]interface{} "message: Post restored, post: Restored post, comments: Number of restored comments"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 403 {object} apierror.Error "The post belongs to another user or was removed by a moderator"
+// @Failure 404 {object} apierror.Error "No deleted post with this id"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/{post_id}/restore [post]
+func RestorePost(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var post models.Post
+	if err := db.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", c.Param("post_id")).First(&post).Error; err != nil {
+		c.Error(apierror.NotFound("post").WithDetail("no deleted post with this id"))
+		return
+	}
+
+	admin, err := hasRole(userID, models.RoleAdmin)
+	if err != nil {
+		c.Error(apierror.Internal("could not restore the post").Wrap(err))
+		return
+	}
+	if !admin {
+		if post.UserID != userID {
+			c.Error(apierror.Forbidden(apierror.CodeNotOwner, "you can only restore your own posts"))
+			return
+		}
+		var removals int64
+		err := db.DB.Model(&models.ModerationAction{}).
+			Where("action = ? AND target_type = ? AND target_id = ?", models.ModerationDelete, models.TargetPost, post.ID).
+			Count(&removals).Error
+		if err != nil || removals > 0 {
+			c.Error(apierror.Forbidden(apierror.CodeRemovedByModerator, "this post was removed by a moderator"))
+			return
+		}
+	}
+
+	var restored int64
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.PostComment{}).
+			Where("post_id = ? AND deleted_with_post = ?", post.ID, true).
+			Updates(map[string]any{"deleted_at": nil, "deleted_with_post": false})
+		if result.Error != nil {
+			return result.Error
+		}
+		restored = result.RowsAffected
+		return tx.Unscoped().Model(&post).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		c.Error(apierror.Internal("could not restore the post").Wrap(err))
+		return
+	}
+	before := post
+	post.DeletedAt = gorm.DeletedAt{}
+	audit.Record(c, audit.Entry{Action: audit.PostRestore, TargetType: models.TargetPost, TargetID: post.ID, Before: before, After: post})
+
+	c.JSON(http.StatusOK, gin.H{"message": "post restored", "post": post, "comments": restored})
+}
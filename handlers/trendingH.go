@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultTrendingWindow = "24h"
+
+// @Summary Get trending posts
+// @Description Retrieve the posts with the most recent views, reactions and comments, best first. Newer activity weighs more than older activity in the window. The ranking is recomputed in the background every few minutes.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param window query string false "Period to rank, one of 24h, 7d and 30d, 24h by default"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "window: Ranked period, computed_at: Time of the ranking, posts: Ranked posts, page: Current page"
+// @Failure 400 {object} apierror.Error "Unknown window"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /post/trending [get]
+func ShowTrending(c *gin.Context) {
+	window := c.DefaultQuery("window", defaultTrendingWindow)
+	if _, ok := analytics.TrendingWindows[window]; !ok {
+		c.Error(apierror.BadRequest(apierror.CodeInvalidParameter, "window must be one of 24h, 7d and 30d"))
+		return
+	}
+	viewerID := currentUserID(c)
+	page, limit, offset := pagination(c)
+
+	// hidden, pending and deleted posts may still be in the ranking until the next run
+	visible := db.DB.Model(&models.Post{}).Select("id").Scopes(visibleTo(viewerID))
+	var ranking []models.TrendingPost
+	err := db.DB.Where("time_window = ? AND post_id IN (?)", window, visible).
+		Order("score DESC, post_id DESC").Limit(limit).Offset(offset).
+		Find(&ranking).Error
+	if err != nil {
+		c.Error(apierror.Internal("could not fetch trending posts").Wrap(err))
+		return
+	}
+
+	postIDs := make([]uint, 0, len(ranking))
+	for _, entry := range ranking {
+		postIDs = append(postIDs, entry.PostID)
+	}
+	var posts []models.Post
+	if err := db.DB.Preload("Mentions").Preload("Tags").Where("id IN ?", append(postIDs, 0)).Find(&posts).Error; err != nil {
+		c.Error(apierror.Internal("could not fetch trending posts").Wrap(err))
+		return
+	}
+	if err := attachPostReactions(posts, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch reactions").Wrap(err))
+		return
+	}
+	if err := attachBookmarks(posts, viewerID); err != nil {
+		c.Error(apierror.Internal("could not fetch bookmarks").Wrap(err))
+		return
+	}
+
+	byID := make(map[uint]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+	ranked := make([]models.RankedPost, 0, len(ranking))
+	for _, entry := range ranking {
+		if post, ok := byID[entry.PostID]; ok {
+			ranked = append(ranked, models.RankedPost{Rank: entry.Rank, Score: entry.Score, Post: post})
+		}
+	}
+
+	response := gin.H{"window": window, "posts": ranked, "page": page}
+	if len(ranking) > 0 {
+		response["computed_at"] = ranking[0].ComputedAt
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -1,17 +1,30 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 
 	"net/http"
 
-	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/audit"
+	"github.com/dayiamin/gin_blog_api/metrics"
+	"github.com/dayiamin/gin_blog_api/middleware"
 	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/services"
 	"github.com/dayiamin/gin_blog_api/utils"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// UserHandler serves the account and profile endpoints on top of a UserService.
+type UserHandler struct {
+	users *services.UserService
+}
+
+func NewUserHandler(users *services.UserService) *UserHandler {
+	return &UserHandler{users: users}
+}
+
 
 // @Summary Register a new user
 // @Description Create a new user account with username, email, and password. Returns a JWT token upon successful registration.
@@ -20,36 +33,31 @@ import (
 // @Produce json
 // @Param user body models.RegisterUsers true "User registration details"
 // @Success 201 {object} map[string]string "message: Success message, token: JWT token"
-// @Failure 400 {object} map[string]string "error: Invalid input"
-// @Failure 500 {object} map[string]string "error: Internal server error (hashing or database issue)"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 409 {object} apierror.Error "User name or email already taken"
+// @Failure 500 {object} apierror.Error "Internal server error (hashing or database issue)"
 // @Router /user/register [post]
-func RegisterUser(c *gin.Context) {
+func (h *UserHandler) RegisterUser(c *gin.Context) {
 	var body models.RegisterUsers
-	if err := c.Bind(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBind(&body); err != nil {
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error in hashing password"})
+	user, err := h.users.Register(body)
+	switch {
+	case errors.Is(err, services.ErrUserExists):
+		c.Error(apierror.Conflict(apierror.CodeUserExists, "the user name or email is already taken"))
 		return
-	}
-
-	user := models.User{
-		UserName: body.UserName,
-		Email:    body.Email,
-		Password: string(hashedPassword),
-	}
-
-	if err := db.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create user in db"})
+	case err != nil:
+		c.Error(apierror.Internal("could not create the user").Wrap(err))
 		return
 	}
 
 	token, err := utils.GenerateJWT(user.ID, user.UserName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
+		c.Error(apierror.Internal("could not generate the token").Wrap(err))
 		return
 	}
 
@@ -66,39 +74,52 @@ func RegisterUser(c *gin.Context) {
 // @Produce json
 // @Param credentials body models.UserLoginRequest true "Login credentials (username or email and password)"
 // @Success 200 {object} map[string]string "message: Success message, token: JWT token"
-// @Failure 400 {object} map[string]string "error: Invalid input"
-// @Failure 401 {object} map[string]string "error: Incorrect username/email or password"
-// @Failure 500 {object} map[string]string "error: Internal server error (JWT generation)"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Incorrect username/email or password"
+// @Failure 403 {object} apierror.Error "Account suspended or banned, with the reason and the end of a suspension"
+// @Failure 500 {object} apierror.Error "Internal server error (JWT generation)"
 // @Router /user/login [post]
-func Login(c *gin.Context) {
+func (h *UserHandler) Login(c *gin.Context) {
 	var input models.UserLoginRequest
 
-	if err := c.Bind(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	var user models.User
-
-	// checking input for username or email
-	if err := db.DB.Where("email = ?", &input.Credential).First(&user).Error; err != nil {
-		if err := db.DB.Where("user_name = ?", &input.Credential).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Username or Email is incorecct"})
-			return
-		}
+	user, err := h.users.Login(input.Credential, input.Password)
+	if err != nil {
+		metrics.Login(false)
 	}
-
-	// decoding the password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "wrong password"})
+	loginFailed := func(reason string) {
+		audit.Record(c, audit.Entry{ActorID: user.ID, Action: audit.LoginFailed, TargetType: models.TargetUser, TargetID: user.ID, After: gin.H{"reason": reason}})
+	}
+	switch {
+	case errors.Is(err, services.ErrUnknownUser):
+		audit.Record(c, audit.Entry{Action: audit.LoginFailed, After: gin.H{"credential_fingerprint": audit.Fingerprint(input.Credential), "reason": "unknown user"}})
+		c.Error(apierror.Unauthorized(apierror.CodeInvalidCredentials, "unknown user name or email"))
+		return
+	case errors.Is(err, services.ErrWrongPassword):
+		loginFailed("wrong password")
+		c.Error(apierror.Unauthorized(apierror.CodeInvalidCredentials, "wrong password"))
+		return
+	case errors.Is(err, services.ErrSuspended):
+		loginFailed("suspended")
+		c.Error(middleware.SuspendedError(user))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not log in").Wrap(err))
 		return
 	}
 
 	token, err := utils.GenerateJWT(user.ID, user.UserName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error in generating jwt"})
+		c.Error(apierror.Internal("could not generate the token").Wrap(err))
 		return
 	}
+	audit.Record(c, audit.Entry{ActorID: user.ID, Action: audit.Login, TargetType: models.TargetUser, TargetID: user.ID})
+	metrics.Login(true)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Loged in successfully",
@@ -107,6 +128,50 @@ func Login(c *gin.Context) {
 
 }
 
+// @Summary Change password
+// @Description Change the password of the authenticated user, the current password must be sent along. Requires JWT authentication.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param passwords body models.PasswordChange true "Current and new password"
+// @Success 200 {object} map[string]string "message: Password changed"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized or wrong current password"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
+// @Router /user/password [put]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	}
+
+	var input models.PasswordChange
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	user, err := h.users.ChangePassword(userIDVal.(uint), input.CurrentPassword, input.NewPassword)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+		return
+	case errors.Is(err, services.ErrWrongPassword):
+		audit.Record(c, audit.Entry{Action: audit.PasswordChange, TargetType: models.TargetUser, TargetID: user.ID, After: gin.H{"result": "wrong current password"}})
+		c.Error(apierror.Unauthorized(apierror.CodeInvalidCredentials, "wrong password"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not change the password").Wrap(err))
+		return
+	}
+	audit.Record(c, audit.Entry{Action: audit.PasswordChange, TargetType: models.TargetUser, TargetID: user.ID, After: gin.H{"result": "changed"}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
 // @Summary Create or update user profile
 // @Description Create a new user profile or update an existing one for the authenticated user. Requires JWT authentication.
 // @Tags profiles
@@ -116,56 +181,32 @@ func Login(c *gin.Context) {
 // @Param profile body models.UserProfileRegister true "User profile details"
 // @Success 201 {object} map[string]interface{} "message: Profile created, profile: User profile data"
 // @Success 200 {object} map[string]interface{} "message: Profile updated, profile: Updated user profile data"
-// @Failure 400 {object} map[string]string "error: Invalid input"
-// @Failure 401 {object} map[string]string "error: Unauthorized (missing or invalid JWT)"
-// @Failure 500 {object} map[string]string "error: Internal server error (database issue)"
+// @Failure 400 {object} apierror.Error "Malformed request body"
+// @Failure 422 {object} apierror.Error "Invalid fields"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
 // @Router /user/profile [post]
-func CreateProfile(c *gin.Context) {
+func (h *UserHandler) CreateProfile(c *gin.Context) {
 	userIDVal, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
 		return
 	}
 
 	var input models.UserProfileRegister
 
-	if err := c.Bind(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	}
-
-	userID := userIDVal.(uint)
-
-	// checking if user profile already exist so we should use the update profile route
-	var existingProfile models.UserProfile
-	if err := db.DB.Where("user_id = ?", userID).First(&existingProfile).Error; err == nil {
-
-		if helperUpdateProfile(input.FirstName) {
-			existingProfile.FirstName = input.FirstName
-		}
-		if helperUpdateProfile(input.LastName) {
-			existingProfile.LastName = input.LastName
-		}
-		if helperUpdateProfile(input.Bio) {
-			existingProfile.Bio = input.Bio
-		}
-		if helperUpdateProfile(input.ProfilePic) {
-			existingProfile.ProfilePic = input.ProfilePic
-		}
-		db.DB.Save(&existingProfile)
-		c.JSON(http.StatusOK, gin.H{"message": "Profile updated", "profile": existingProfile})
+	if err := c.ShouldBind(&input); err != nil {
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	profile := models.UserProfile{
-		FirstName:  input.FirstName,
-		LastName:   input.LastName,
-		Bio:        input.Bio,
-		ProfilePic: input.ProfilePic,
-		UserID:     userID,
+	profile, created, err := h.users.SaveProfile(userIDVal.(uint), input)
+	if err != nil {
+		c.Error(apierror.Internal("could not save profile").Wrap(err))
+		return
 	}
-
-	if err := db.DB.Create(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create profile"})
+	if !created {
+		c.JSON(http.StatusOK, gin.H{"message": "Profile updated", "profile": profile})
 		return
 	}
 
@@ -173,14 +214,6 @@ func CreateProfile(c *gin.Context) {
 
 }
 
-func helperUpdateProfile(input string) bool {
-	if len(input) == 0 || input == "" {
-		return false
-	}
-	return true
-}
-
-
 // @Summary Get user profile
 // @Description Retrieve the profile details of a user by their username. Requires JWT authentication.
 // @Tags profiles
@@ -188,23 +221,31 @@ func helperUpdateProfile(input string) bool {
 // @Produce json
 // @Security JWT
 // @Param user_name path string true "Username of the user"
-// @Success 200 {object} map[string]models.UserProfile "profile: User profile data"
-// @Failure 400 {object} map[string]string "error: User or profile not found"
-// @Failure 401 {object} map[string]string "error: Unauthorized (missing or invalid JWT)"
+// @Success 200 {object} map[string]interface{} "profile: User profile data, followers_count: Number of followers, following_count: Number of followed users, is_following, is_blocked, is_muted: Relation of the authenticated user to this user"
+// @Failure 404 {object} apierror.Error "User or profile not found"
+// @Failure 401 {object} apierror.Error "Unauthorized (missing or invalid JWT)"
+// @Failure 500 {object} apierror.Error "Internal server error (database issue)"
 // @Router /user/profile/{user_name} [get]
-func ShowProfile(c *gin.Context){
-	userName := c.Param("user_name")
-
-	var user models.User
-	if err := db.DB.Where("user_name = ?", userName).First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+func (h *UserHandler) ShowProfile(c *gin.Context) {
+	view, err := h.users.Profile(currentUserID(c), c.Param("user_name"))
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.Error(apierror.NotFound("user"))
 		return
-	}
-	var profile models.UserProfile
-	if err := db.DB.Where("user_id = ?", user.ID).First(&profile).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User profile not found"})
+	case errors.Is(err, services.ErrNoProfile):
+		c.Error(apierror.NotFound("profile"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal("could not fetch the profile").Wrap(err))
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{"profile": profile})
-}
\ No newline at end of file
+
+	response := gin.H{"profile": view.Profile, "followers_count": view.Followers, "following_count": view.Following}
+	if view.Relation != nil {
+		response["is_following"] = view.Relation.Following
+		response["is_blocked"] = view.Relation.Blocking
+		response["is_muted"] = view.Relation.Muting
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,39 @@
+// Package jobs runs the background work of the API, such as purging the trash, on
+// timers next to the HTTP server.
+package jobs
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/config"
+)
+
+// Start launches every background job at the intervals of cfg. It returns right away.
+func Start(cfg config.Config) {
+	go Every("purge trash", cfg.Trash.PurgeInterval.Duration, PurgeTrash)
+
+	analytics.Start()
+	go Every("roll up views", cfg.Analytics.RollupInterval.Duration, analytics.RollUp)
+	go Every("compute trending", cfg.Analytics.TrendingInterval.Duration, analytics.ComputeTrending)
+	go Every("refresh metrics", cfg.Metrics.RefreshInterval.Duration, RefreshMetrics)
+}
+
+// Every runs job once right away and then every interval until the process exits.
+// Errors are logged and the job runs again at the next tick.
+func Every(name string, interval time.Duration, job func() error) {
+	if interval <= 0 {
+		slog.Info("job is turned off", "job", name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := job(); err != nil {
+			slog.Error("job failed", "job", name, "error", err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/metrics"
+	"github.com/dayiamin/gin_blog_api/models"
+)
+
+// RefreshMetrics counts the posts and comments by status and the users for the
+// content gauges of /metrics.
+func RefreshMetrics() error {
+	var content metrics.Content
+	var err error
+	if content.Posts, err = countByStatus(&models.Post{}); err != nil {
+		return err
+	}
+	if content.Comments, err = countByStatus(&models.PostComment{}); err != nil {
+		return err
+	}
+	if err := db.DB.Model(&models.User{}).Count(&content.Users).Error; err != nil {
+		return err
+	}
+	metrics.SetContent(content, models.StatusPublished, models.StatusPending, models.StatusHidden)
+	return nil
+}
+
+func countByStatus(model any) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.DB.Model(model).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+)
+
+// trashRetention is set from the configuration by ConfigureTrash.
+var trashRetention = config.Default().Trash.Retention.Duration
+
+// ConfigureTrash sets how long deleted content stays restorable.
+func ConfigureTrash(cfg config.Trash) {
+	trashRetention = cfg.Retention.Duration
+}
+
+// TrashRetention is how long deleted posts and comments stay restorable before the
+// purger removes them for good, 0 keeps them forever.
+func TrashRetention() time.Duration {
+	return trashRetention
+}
+
+// PurgeTrash hard-deletes the posts and comments deleted longer than TrashRetention
+// ago, together with the reactions, mentions, notifications, reports, bookmarks and
+// view stats pointing at them. Moderation actions stay as the record of what was done,
+// without the report they resolved.
+func PurgeTrash() error {
+	retention := TrashRetention()
+	if retention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-retention)
+
+	var postIDs, commentIDs []uint
+	err := db.DB.Unscoped().Model(&models.Post{}).Where("deleted_at < ?", cutoff).Pluck("id", &postIDs).Error
+	if err != nil {
+		return err
+	}
+	err = db.DB.Unscoped().Model(&models.PostComment{}).
+		Where("deleted_at < ? OR post_id IN ?", cutoff, append(postIDs, 0)).
+		Pluck("id", &commentIDs).Error
+	if err != nil {
+		return err
+	}
+	if len(postIDs) == 0 && len(commentIDs) == 0 {
+		return nil
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, target := range []struct {
+			targetType string
+			ids        []uint
+			model      any
+			column     string // the column of notifications pointing at the target
+		}{
+			{models.TargetComment, commentIDs, &models.PostComment{}, "comment_id"},
+			{models.TargetPost, postIDs, &models.Post{}, "post_id"},
+		} {
+			if len(target.ids) == 0 {
+				continue
+			}
+			if err := tx.Where("target_type = ? AND target_id IN ?", target.targetType, target.ids).Delete(&models.Reaction{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("source_type = ? AND source_id IN ?", target.targetType, target.ids).Delete(&models.Mention{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where(target.column+" IN ?", target.ids).Delete(&models.Notification{}).Error; err != nil {
+				return err
+			}
+			reports := tx.Model(&models.Report{}).Select("id").Where("target_type = ? AND target_id IN ?", target.targetType, target.ids)
+			if err := tx.Model(&models.ModerationAction{}).Where("report_id IN (?)", reports).Update("report_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("target_type = ? AND target_id IN ?", target.targetType, target.ids).Delete(&models.Report{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ?", target.ids).Delete(target.model).Error; err != nil {
+				return err
+			}
+		}
+		if len(postIDs) == 0 {
+			return nil
+		}
+		if err := tx.Exec("DELETE FROM post_tags WHERE post_id IN ?", postIDs).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&models.Bookmark{}, &models.PostView{}, &models.PostDailyStat{}, &models.PostReferrerStat{}, &models.TrendingPost{}} {
+			if err := tx.Where("post_id IN ?", postIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("purged the trash", "posts", len(postIDs), "comments", len(commentIDs))
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package logging sets up the structured logs of the API: records written through
+// log/slog as JSON, sensitive values redacted, and loggers scoped to one request.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dayiamin/gin_blog_api/config"
+)
+
+// Redacted replaces the value of sensitive attributes.
+const Redacted = "[REDACTED]"
+
+// sensitive lists the attribute keys, header names and query parameters whose values
+// never reach the logs, compared in lower case.
+var sensitive = map[string]bool{
+	"password":         true,
+	"current_password": true,
+	"new_password":     true,
+	"authorization":    true,
+	"cookie":           true,
+	"set-cookie":       true,
+	"token":            true,
+	"access_token":     true,
+	"secret":           true,
+}
+
+// Sensitive reports whether values under key are redacted.
+func Sensitive(key string) bool {
+	return sensitive[strings.ToLower(key)]
+}
+
+// New returns a logger writing records of cfg.Level and above to w in cfg.Format.
+func New(cfg config.Log, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level: Level(cfg.Level),
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if Sensitive(attr.Key) {
+				attr.Value = slog.StringValue(Redacted)
+			}
+			return attr
+		},
+	}
+	if cfg.Format == config.FormatText {
+		return slog.New(slog.NewTextHandler(w, options))
+	}
+	return slog.New(slog.NewJSONHandler(w, options))
+}
+
+// Setup makes a logger for cfg writing to stdout the default one, the log package
+// writes through it too.
+func Setup(cfg config.Log) *slog.Logger {
+	logger := New(cfg, os.Stdout)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// Level converts a configured level name, unknown names are info.
+func Level(name string) slog.Level {
+	switch name {
+	case config.LevelDebug:
+		return slog.LevelDebug
+	case config.LevelWarn:
+		return slog.LevelWarn
+	case config.LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of ctx, or the default logger when ctx has none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RedactQuery returns a raw query string with the values of sensitive parameters
+// replaced, like the access_token of event streams.
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Redacted
+	}
+	redacted := false
+	for key := range values {
+		if Sensitive(key) {
+			values[key] = []string{Redacted}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawQuery
+	}
+	return values.Encode()
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/config"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(config.Log{Level: config.LevelInfo, Format: config.FormatJSON}, &buf)
+	logger.Info("login", "user_name", "alice", "Password", "secret1", slog.Group("request", "authorization", "Bearer abc"))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["user_name"] != "alice" || record["Password"] != Redacted {
+		t.Errorf("record = %v", record)
+	}
+	if group, _ := record["request"].(map[string]any); group["authorization"] != Redacted {
+		t.Errorf("request group = %v", record["request"])
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(config.Log{Level: config.LevelWarn}, &buf)
+	logger.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("an info record was written at warn level: %s", buf.String())
+	}
+	logger.Warn("kept")
+	if buf.Len() == 0 {
+		t.Error("a warn record was dropped at warn level")
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"page=2&limit=10", "page=2&limit=10"},
+		{"access_token=abc&page=2", "access_token=%5BREDACTED%5D&page=2"},
+	}
+	for _, tt := range tests {
+		if got := RedactQuery(tt.query); got != tt.want {
+			t.Errorf("RedactQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("a context without a logger should give the default logger")
+	}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if FromContext(NewContext(context.Background(), logger)) != logger {
+		t.Error("the logger of the context was not returned")
+	}
+}
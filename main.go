@@ -2,27 +2,30 @@ package main
 
 import (
 
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/config"
 	"github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/feeds"
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/jobs"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/dayiamin/gin_blog_api/moderation"
+	"github.com/dayiamin/gin_blog_api/repository"
 	"github.com/dayiamin/gin_blog_api/routes"
+	"github.com/dayiamin/gin_blog_api/services"
+	"github.com/dayiamin/gin_blog_api/sitemap"
+	"github.com/dayiamin/gin_blog_api/utils"
 	_ "github.com/dayiamin/gin_blog_api/docs"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	"log"
+	"log/slog"
+	"os"
 	"github.com/swaggo/gin-swagger"
 	"github.com/swaggo/files"
 )
 
 // TODO  write handler for creating user login users and creating posts and comments
 
-// load the init file
-func init(){
-	var envErr = godotenv.Load()
-	if envErr != nil{
-		log.Fatal("env error")
-	}
-}
-
-
 // @title Blog Post api
 // @version 1.0
 // @description this is api for creating users and posting blogs and comments
@@ -32,14 +35,59 @@ func init(){
 // @in header
 // @name Authorization
 func main(){
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("could not load the configuration", err)
+	}
+	logging.Setup(cfg.Log)
+	if cfg.Log.Level != config.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	utils.ConfigureJWT(cfg.JWT)
+	services.ConfigureComments(cfg.Comments)
+	repository.Configure(cfg.Moderation)
+	if err := moderation.Configure(cfg.Moderation); err != nil {
+		fatal("could not set up the moderation filters", err)
+	}
+	jobs.ConfigureTrash(cfg.Trash)
+	analytics.Configure(cfg.Analytics)
+	feeds.Configure(cfg.Site)
+	handlers.Configure(cfg)
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Logger(), middleware.Metrics(), middleware.Errors(), middleware.Recovery())
+	router.NoRoute(middleware.NoRoute)
+	routes.MetricsRoutes(router)
 	v1Router := router.Group("/api/v1")
 	v1Router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	db.Connect()
+	if err := db.Connect(cfg.Database); err != nil {
+		fatal("could not connect to the database", err)
+	}
+	db.PromoteAdmins(cfg.Site.AdminUsers)
+	if err := sitemap.Watch(db.DB); err != nil {
+		fatal("could not watch the sitemap", err)
+	}
 	
-	routes.UserRoutes(v1Router)
-	routes.PostRoutes(v1Router)
+	store := repository.NewStore(db.DB)
+	routes.UserRoutes(v1Router, handlers.NewUserHandler(services.NewUserService(store)))
+	comments := handlers.NewCommentHandler(services.NewCommentService(store))
+	routes.PostRoutes(v1Router, handlers.NewPostHandler(services.NewPostService(store)), comments)
+	routes.FeedRoutes(v1Router)
+	routes.NotificationRoutes(v1Router)
+	routes.MeRoutes(v1Router)
+	routes.ModerationRoutes(v1Router, comments)
+	routes.AdminRoutes(v1Router)
+
+	jobs.Start(cfg)
+
+	slog.Info("listening", "addr", cfg.Server.Addr)
+	if err := router.Run(cfg.Server.Addr); err != nil {
+		fatal("server stopped", err)
+	}
+}
 
-	router.Run(":8080")			
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
\ No newline at end of file
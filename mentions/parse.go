@@ -0,0 +1,70 @@
+// Package mentions finds @username mentions in user written text.
+package mentions
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is one @username found in a text. Offset and Length count Unicode code points
+// and cover the whole mention including the "@".
+type Span struct {
+	UserName string
+	Offset   int
+	Length   int
+}
+
+// Parse returns the mentions of text in order of appearance. A mention starts with
+// "@" at the start of the text or after a character that is not part of a name, which
+// keeps email addresses out, and runs over letters, digits, "_", "-" and ".".
+// A trailing "." is treated as punctuation.
+func Parse(text string) []Span {
+	var spans []Span
+	var prev rune
+	runeOffset := 0
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '@' && !isNameRune(prev) {
+			name := readName(text[i+size:])
+			if name != "" {
+				length := utf8.RuneCountInString(name) + 1
+				spans = append(spans, Span{UserName: name, Offset: runeOffset, Length: length})
+				i += size + len(name)
+				runeOffset += length
+				prev, _ = utf8.DecodeLastRuneInString(name)
+				continue
+			}
+		}
+		prev = r
+		i += size
+		runeOffset++
+	}
+	return spans
+}
+
+// UserNames returns the distinct names mentioned in the spans.
+func UserNames(spans []Span) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, span := range spans {
+		if !seen[span.UserName] {
+			seen[span.UserName] = true
+			names = append(names, span.UserName)
+		}
+	}
+	return names
+}
+
+func readName(text string) string {
+	end := strings.IndexFunc(text, func(r rune) bool { return !isNameRune(r) })
+	if end == -1 {
+		end = len(text)
+	}
+	return strings.TrimRight(text[:end], ".")
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
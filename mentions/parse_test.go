@@ -0,0 +1,43 @@
+package mentions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Span
+	}{
+		{name: "no mention", text: "hello world"},
+		{name: "start of the text", text: "@alice hi", want: []Span{{UserName: "alice", Offset: 0, Length: 6}}},
+		{name: "name characters", text: "cc @a_b-c.d", want: []Span{{UserName: "a_b-c.d", Offset: 3, Length: 8}}},
+		{name: "multi-byte text before", text: "héllo wörld @bob", want: []Span{{UserName: "bob", Offset: 12, Length: 4}}},
+		{name: "multi-byte name", text: "😀 @jürgen!", want: []Span{{UserName: "jürgen", Offset: 2, Length: 7}}},
+		{name: "email address", text: "mail alice@example.com"},
+		{name: "trailing dot", text: "thanks @bob.", want: []Span{{UserName: "bob", Offset: 7, Length: 4}}},
+		{name: "trailing dots", text: "thanks @bob...", want: []Span{{UserName: "bob", Offset: 7, Length: 4}}},
+		{name: "second @ inside a name", text: "@a@b", want: []Span{{UserName: "a", Offset: 0, Length: 2}}},
+		{name: "lone @", text: "@ alice"},
+		{name: "repeated name", text: "@bob and @bob", want: []Span{
+			{UserName: "bob", Offset: 0, Length: 4},
+			{UserName: "bob", Offset: 9, Length: 4},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserNames(t *testing.T) {
+	got := UserNames(Parse("@bob @alice @bob"))
+	if want := []string{"bob", "alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UserNames = %v, want %v", got, want)
+	}
+}
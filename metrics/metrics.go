@@ -0,0 +1,122 @@
+// Package metrics exposes the health of the API in the Prometheus format: HTTP
+// requests, database queries, logins and the amount of content.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds the metrics of the API next to the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// UnmatchedRoute labels requests that matched no route, so unknown paths can not grow
+// the number of series.
+const UnmatchedRoute = "unmatched"
+
+var (
+	requests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	queryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Time taken by database queries, by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table"})
+
+	queryErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Database queries that failed, by operation and table. Missing records are not errors.",
+	}, []string{"operation", "table"})
+
+	logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Login attempts, by result: success or failure.",
+	}, []string{"result"})
+
+	// the content gauges are refreshed by a background job, see SetContent
+	posts = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blog_posts",
+		Help: "Posts that are not deleted, by status.",
+	}, []string{"status"})
+
+	comments = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blog_comments",
+		Help: "Comments that are not deleted, by status.",
+	}, []string{"status"})
+
+	users = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "blog_users",
+		Help: "Registered users.",
+	})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRequest counts a served request. route is the route template, like
+// /api/v1/post/:id, or empty when no route matched.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	requestDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// Login counts a login attempt.
+func Login(success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	logins.WithLabelValues(result).Inc()
+}
+
+// Content is the amount of content the gauges report, posts and comments by status.
+type Content struct {
+	Posts    map[string]int64
+	Comments map[string]int64
+	Users    int64
+}
+
+// SetContent updates the content gauges. statuses are the statuses always reported,
+// so a status whose last post is gone drops to 0 instead of keeping its old value.
+func SetContent(content Content, statuses ...string) {
+	for _, status := range statuses {
+		posts.WithLabelValues(status).Set(float64(content.Posts[status]))
+		comments.WithLabelValues(status).Set(float64(content.Comments[status]))
+	}
+	for status, count := range content.Posts {
+		posts.WithLabelValues(status).Set(float64(count))
+	}
+	for status, count := range content.Comments {
+		comments.WithLabelValues(status).Set(float64(count))
+	}
+	users.Set(float64(content.Users))
+}
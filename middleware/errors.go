@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Errors renders the last error a handler recorded with c.Error as a problem+json
+// response, unless the handler already wrote a response. Errors that are not problems
+// are answered with a generic internal error. Problems with a status of 500 and above
+// are logged with their cause.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		problem := apierror.From(last.Err)
+		if problem.Status >= http.StatusInternalServerError {
+			logging.FromContext(c.Request.Context()).Error("request failed",
+				"path", c.Request.URL.Path, "code", problem.Code, "detail", problem.Detail, "error", problem.Cause)
+		}
+
+		rendered := *problem
+		rendered.Instance = c.Request.URL.Path
+		c.Header("Content-Type", apierror.ContentType)
+		c.JSON(rendered.Status, &rendered)
+	}
+}
+
+// NoRoute answers requests to unknown paths with a problem.
+func NoRoute(c *gin.Context) {
+	c.Error(apierror.NotFound("route").WithDetail("no route matches " + c.Request.Method + " " + c.Request.URL.Path))
+}
@@ -1,44 +1,129 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"strings"
+	"time"
 
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
 	"github.com/dayiamin/gin_blog_api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var errMissingToken = errors.New("authorization header missing or invalid")
 
+func JwtAuth() gin.HandlerFunc {
+	return jwtAuth(false)
+}
 
+// JwtAuthQuery works like JwtAuth but also accepts the token in the access_token query
+// parameter, for EventSource clients that can not set headers.
+func JwtAuthQuery() gin.HandlerFunc {
+	return jwtAuth(true)
+}
 
-func JwtAuth() gin.HandlerFunc{
-	
-	return func(c *gin.Context){
+func jwtAuth(allowQuery bool) gin.HandlerFunc {
 
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing or invalid"})
+	return func(c *gin.Context) {
+
+		claims, err := parseToken(c, allowQuery)
+		if errors.Is(err, errMissingToken) {
+			c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authorization header missing or invalid"))
 			c.Abort()
 			return
 		}
 
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
-			return utils.JwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		if err != nil {
+			c.Error(apierror.Unauthorized(apierror.CodeInvalidToken, "invalid or expired token"))
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", uint(claims["user_id"].(float64))) // JWT numbers are float64
+		setClaims(c, claims)
+
+		// checked on every request so a suspension takes effect on tokens already issued
+		user, err := currentUser(claims)
+		if err != nil {
+			c.Error(apierror.Unauthorized(apierror.CodeInvalidToken, "invalid or expired token"))
+			c.Abort()
+			return
+		}
+		if user.Suspended(time.Now()) {
+			c.Error(SuspendedError(user))
+			c.Abort()
+			return
 		}
 
 		c.Next()
 	}
 
-}
\ No newline at end of file
+}
+
+// SuspendedError is the problem sent to a suspended or banned user, with the reason
+// and the end of a suspension as extra members.
+func SuspendedError(user models.User) *apierror.Error {
+	if user.Banned {
+		return apierror.Forbidden(apierror.CodeBanned, "your account is banned").
+			With("reason", user.SuspensionReason)
+	}
+	return apierror.Forbidden(apierror.CodeSuspended, "your account is suspended").
+		With("reason", user.SuspensionReason).
+		With("suspended_until", user.SuspendedUntil)
+}
+
+// currentUser loads the suspension state of the user the token belongs to.
+func currentUser(claims jwt.MapClaims) (models.User, error) {
+	userID, _ := claims["user_id"].(float64)
+
+	var user models.User
+	err := db.DB.Select("id", "banned", "suspended_until", "suspension_reason").
+		Where("id = ?", uint(userID)).First(&user).Error
+	return user, err
+}
+
+// OptionalJwtAuth sets the user_id of a valid token like JwtAuth but lets anonymous
+// requests through, for public endpoints that personalize their response. Suspended
+// users are served like anonymous visitors.
+func OptionalJwtAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, err := parseToken(c, false); err == nil {
+			if user, err := currentUser(claims); err == nil && !user.Suspended(time.Now()) {
+				setClaims(c, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+func parseToken(c *gin.Context, allowQuery bool) (jwt.MapClaims, error) {
+	authHeader := c.GetHeader("Authorization")
+	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+	if allowQuery && authHeader == "" {
+		tokenStr = c.Query("access_token")
+	} else if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, errMissingToken
+	}
+	if tokenStr == "" {
+		return nil, errMissingToken
+	}
+
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
+		return utils.JwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	return claims, nil
+}
+
+func setClaims(c *gin.Context, claims jwt.MapClaims) {
+	if userID, ok := claims["user_id"].(float64); ok {
+		c.Set("user_id", uint(userID)) // JWT numbers are float64
+		setUserLogger(c, uint(userID))
+	}
+}
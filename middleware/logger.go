@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Logger gives every request a logger carrying its request id, stored in the request
+// context where logging.FromContext finds it, and writes an access log line once the
+// request is served. It runs after RequestID.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		logger := slog.Default().With("request_id", c.GetString("request_id"))
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if query := logging.RedactQuery(c.Request.URL.RawQuery); query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if last := c.Errors.Last(); last != nil {
+			attrs = append(attrs, slog.String("error", last.Error()))
+		}
+
+		// the logger of the context carries the user id once JwtAuth ran
+		logger = logging.FromContext(c.Request.Context())
+		if logger.Enabled(c, slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("headers", headers(c.Request.Header)))
+		}
+		logger.LogAttrs(c, level(status), "request", attrs...)
+	}
+}
+
+// Recovery answers a request whose handler panicked with an internal error caused by
+// the panic, which Errors logs. http.ErrAbortHandler is passed on so the server breaks
+// the connection of a response that can not be completed.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		c.Error(apierror.Internal("the request could not be completed").Wrap(fmt.Errorf("panic: %v", recovered)))
+		c.Abort()
+	})
+}
+
+// setUserLogger adds the id of the authenticated user to the request logger.
+func setUserLogger(c *gin.Context, userID uint) {
+	logger := logging.FromContext(c.Request.Context()).With("user_id", userID)
+	c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+}
+
+func level(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// headers returns the request headers as log attributes, values of sensitive headers
+// like Authorization redacted.
+func headers(header http.Header) map[string]string {
+	values := make(map[string]string, len(header))
+	for name := range header {
+		if logging.Sensitive(name) {
+			values[name] = logging.Redacted
+			continue
+		}
+		values[name] = header.Get(name)
+	}
+	return values
+}
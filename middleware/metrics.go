@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics counts every request and how long it took, labeled by the route template
+// like /api/v1/post/:id rather than the path so ids don't make new series.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
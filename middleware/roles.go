@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets the request through only when the authenticated user has one of
+// the given roles. It must run after JwtAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDVal, exists := c.Get("user_id")
+		if !exists {
+			c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := db.DB.Select("id", "role").Where("id = ?", userIDVal).First(&user).Error; err != nil {
+			c.Error(apierror.Unauthorized(apierror.CodeUnauthorized, "authentication required"))
+			c.Abort()
+			return
+		}
+		if !slices.Contains(roles, user.Role) {
+			c.Error(apierror.Forbidden(apierror.CodeForbidden, "you do not have permission to do this"))
+			c.Abort()
+			return
+		}
+
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
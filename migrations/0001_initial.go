@@ -0,0 +1,222 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The initial schema is described by copies of the models as they were when
+// migrations were introduced, so later changes to the models don't change what this
+// migration does. On databases created by the former AutoMigrate on boot it only adds
+// what is missing, which makes it safe to apply to existing installs.
+func init() {
+	register(Migration{Version: "0001", Name: "initial", Up: initialUp, Down: initialDown})
+}
+
+func initialUp(tx *gorm.DB) error {
+	return tx.AutoMigrate(initialModels()...)
+}
+
+func initialDown(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable("post_tags"); err != nil {
+		return err
+	}
+	models := initialModels()
+	for i := len(models) - 1; i >= 0; i-- {
+		if err := tx.Migrator().DropTable(models[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initialModels lists the tables of the initial schema, tables referenced by foreign
+// keys come before the tables holding them.
+func initialModels() []any {
+	type BaseModel struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+	type Mention struct {
+		ID         uint   `gorm:"primaryKey"`
+		SourceType string `gorm:"size:20;not null;index:idx_mention_source"`
+		SourceID   uint   `gorm:"not null;index:idx_mention_source"`
+		UserID     uint   `gorm:"not null;index"`
+		UserName   string
+		Offset     int
+		Length     int
+	}
+	type Tag struct {
+		ID   uint   `gorm:"primaryKey"`
+		Name string `gorm:"size:50;not null;uniqueIndex"`
+	}
+	type PostComment struct {
+		BaseModel
+		Text       string
+		UserID     uint  `gorm:"not null"`
+		PostID     uint  `gorm:"not null"`
+		ParentID   *uint `gorm:"index"`
+		Depth      int
+		ReplyCount int
+		Deleted    bool
+		Status     string    `gorm:"size:20;not null;default:published;index"`
+		Mentions   []Mention `gorm:"polymorphic:Source;polymorphicValue:comment"`
+	}
+	type Post struct {
+		BaseModel
+		PicAddres   string
+		Title       string
+		Caption     string
+		PostComment []PostComment `gorm:"constraint:OnDelete:CASCADE;"`
+		UserID      uint          `gorm:"index"`
+		Status      string        `gorm:"size:20;not null;default:published;index"`
+		Mentions    []Mention     `gorm:"polymorphic:Source;polymorphicValue:post"`
+		Tags        []Tag         `gorm:"many2many:post_tags"`
+	}
+	type UserProfile struct {
+		BaseModel
+		FirstName  string
+		LastName   string
+		Bio        string
+		ProfilePic string
+		UserID     uint
+	}
+	type User struct {
+		BaseModel
+		UserName         string `gorm:"size:100;unique"`
+		Email            string `gorm:"size:100;unique"`
+		Password         string
+		Role             string `gorm:"size:20;not null;default:user"`
+		SuspendedUntil   *time.Time
+		SuspensionReason string
+		Banned           bool        `gorm:"not null;default:false;index"`
+		UserProfile      UserProfile `gorm:"constraint:OnDelete:CASCADE;"`
+		Posts            []Post      `gorm:"constraint:OnDelete:CASCADE;"`
+		PostComment      []PostComment
+	}
+	type Reaction struct {
+		ID         uint `gorm:"primaryKey"`
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+		UserID     uint   `gorm:"not null;uniqueIndex:idx_reaction_user_target"`
+		TargetType string `gorm:"size:20;not null;uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+		TargetID   uint   `gorm:"not null;uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+		Type       string `gorm:"size:20;not null"`
+	}
+	type Follow struct {
+		FollowerID uint `gorm:"primaryKey;autoIncrement:false"`
+		FolloweeID uint `gorm:"primaryKey;autoIncrement:false;index"`
+		CreatedAt  time.Time
+	}
+	type Notification struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		UserID    uint   `gorm:"not null;index:idx_notification_user_read"`
+		ActorID   uint   `gorm:"not null"`
+		Type      string `gorm:"size:20;not null"`
+		PostID    *uint
+		CommentID *uint
+		ReadAt    *time.Time `gorm:"index:idx_notification_user_read"`
+	}
+	type NotificationPreference struct {
+		UserID  uint   `gorm:"primaryKey;autoIncrement:false"`
+		Type    string `gorm:"primaryKey;size:20"`
+		Enabled bool
+	}
+	type Block struct {
+		UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+		BlockedID uint `gorm:"primaryKey;autoIncrement:false;index"`
+		CreatedAt time.Time
+	}
+	type Mute struct {
+		UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+		MutedID   uint `gorm:"primaryKey;autoIncrement:false;index"`
+		CreatedAt time.Time
+	}
+	type Report struct {
+		ID           uint `gorm:"primaryKey"`
+		CreatedAt    time.Time
+		UpdatedAt    time.Time
+		ReporterID   uint   `gorm:"not null;uniqueIndex:idx_report_reporter_target"`
+		TargetType   string `gorm:"size:20;not null;uniqueIndex:idx_report_reporter_target;index:idx_report_target"`
+		TargetID     uint   `gorm:"not null;uniqueIndex:idx_report_reporter_target;index:idx_report_target"`
+		TargetUserID uint   `gorm:"not null;index"`
+		Reason       string `gorm:"size:30;not null;index"`
+		Note         string
+		Status       string `gorm:"size:20;not null;default:open;index"`
+		ResolvedByID *uint
+		ResolvedAt   *time.Time
+	}
+	type ModerationAction struct {
+		ID           uint `gorm:"primaryKey"`
+		CreatedAt    time.Time
+		ModeratorID  uint   `gorm:"index"`
+		Action       string `gorm:"size:20;not null"`
+		TargetType   string `gorm:"size:20;not null;index:idx_moderation_target"`
+		TargetID     uint   `gorm:"not null;index:idx_moderation_target"`
+		TargetUserID uint   `gorm:"index"`
+		ReportID     *uint
+		Note         string
+	}
+	type AuditLog struct {
+		ID         uint      `gorm:"primaryKey"`
+		CreatedAt  time.Time `gorm:"index"`
+		ActorID    uint      `gorm:"index"`
+		Action     string    `gorm:"size:50;not null;index"`
+		TargetType string    `gorm:"size:20;index:idx_audit_target"`
+		TargetID   uint      `gorm:"index:idx_audit_target"`
+		IP         string    `gorm:"size:64"`
+		UserAgent  string
+		RequestID  string `gorm:"size:64;index"`
+		Before     string `gorm:"type:text"`
+		After      string `gorm:"type:text"`
+	}
+	type BookmarkCollection struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		UserID    uint   `gorm:"not null;uniqueIndex:idx_collection_user_name"`
+		Name      string `gorm:"size:100;not null;uniqueIndex:idx_collection_user_name"`
+	}
+	type Bookmark struct {
+		UserID       uint  `gorm:"primaryKey;autoIncrement:false"`
+		PostID       uint  `gorm:"primaryKey;autoIncrement:false;index"`
+		CollectionID *uint `gorm:"index"`
+		CreatedAt    time.Time
+	}
+	type PostView struct {
+		ID         uint      `gorm:"primaryKey"`
+		CreatedAt  time.Time `gorm:"index"`
+		PostID     uint      `gorm:"not null;index:idx_view_post_visitor"`
+		VisitorKey string    `gorm:"size:64;not null;index:idx_view_post_visitor"`
+		Referrer   string    `gorm:"size:255"`
+		RolledUp   bool      `gorm:"not null;default:false;index"`
+	}
+	type PostDailyStat struct {
+		PostID uint   `gorm:"primaryKey;autoIncrement:false"`
+		Day    string `gorm:"primaryKey;size:10"`
+		Views  int64  `gorm:"not null;default:0"`
+	}
+	type PostReferrerStat struct {
+		PostID   uint   `gorm:"primaryKey;autoIncrement:false"`
+		Day      string `gorm:"primaryKey;size:10"`
+		Referrer string `gorm:"primaryKey;size:255"`
+		Views    int64  `gorm:"not null;default:0"`
+	}
+	type TrendingPost struct {
+		Window     string  `gorm:"column:time_window;primaryKey;size:5"`
+		PostID     uint    `gorm:"primaryKey;autoIncrement:false"`
+		Rank       int     `gorm:"not null;index"`
+		Score      float64 `gorm:"not null"`
+		ComputedAt time.Time
+	}
+
+	return []any{
+		&User{}, &UserProfile{}, &Post{}, &PostComment{}, &Reaction{}, &Follow{},
+		&Notification{}, &NotificationPreference{}, &Mention{}, &Block{}, &Mute{},
+		&Report{}, &ModerationAction{}, &AuditLog{}, &BookmarkCollection{}, &Bookmark{},
+		&PostView{}, &PostDailyStat{}, &PostReferrerStat{}, &TrendingPost{}, &Tag{},
+	}
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// held_from keeps the status of a post or comment whose edit the moderation filters
+// held back, so approving the edit restores it instead of publishing.
+func init() {
+	register(Migration{Version: "0002", Name: "held_from", Up: heldFromUp, Down: heldFromDown})
+}
+
+type heldFromPost struct {
+	HeldFrom string `gorm:"size:20"`
+}
+
+func (heldFromPost) TableName() string { return "posts" }
+
+type heldFromComment struct {
+	HeldFrom string `gorm:"size:20"`
+}
+
+func (heldFromComment) TableName() string { return "post_comments" }
+
+func heldFromUp(tx *gorm.DB) error {
+	for _, model := range []any{&heldFromPost{}, &heldFromComment{}} {
+		if tx.Migrator().HasColumn(model, "HeldFrom") {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(model, "HeldFrom"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func heldFromDown(tx *gorm.DB) error {
+	for _, model := range []any{&heldFromPost{}, &heldFromComment{}} {
+		if err := tx.Migrator().DropColumn(model, "HeldFrom"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// deleted_with_post marks the comments trashed together with their post. Comments
+// trashed before it existed carry the deletion time of their post, which is compared
+// once here, inside the database that stored both.
+func init() {
+	register(Migration{Version: "0003", Name: "deleted_with_post", Up: deletedWithPostUp, Down: deletedWithPostDown})
+}
+
+type deletedWithPostComment struct {
+	DeletedWithPost bool `gorm:"not null;default:false"`
+}
+
+func (deletedWithPostComment) TableName() string { return "post_comments" }
+
+func deletedWithPostUp(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&deletedWithPostComment{}, "DeletedWithPost") {
+		if err := tx.Migrator().AddColumn(&deletedWithPostComment{}, "DeletedWithPost"); err != nil {
+			return err
+		}
+	}
+	return tx.Exec(`UPDATE post_comments SET deleted_with_post = ? WHERE deleted_at IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts WHERE posts.id = post_comments.post_id AND posts.deleted_at = post_comments.deleted_at)`, true).Error
+}
+
+func deletedWithPostDown(tx *gorm.DB) error {
+	return tx.Migrator().DropColumn(&deletedWithPostComment{}, "DeletedWithPost")
+}
@@ -0,0 +1,75 @@
+// Package migrations keeps the versioned changes of the database schema. Each migration
+// lives in its own file named after its version and name, like 0001_initial.go, and
+// registers itself from an init function. Applied migrations are recorded with a
+// checksum of their file, so a migration edited after it ran is reported instead of
+// silently diverging from the databases it was applied to.
+package migrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed *.go
+var sources embed.FS
+
+// Migration is one versioned change of the schema. Up applies it and Down reverts it,
+// both run in a transaction together with the bookkeeping of the migration table.
+type Migration struct {
+	Version  string
+	Name     string
+	Up       func(tx *gorm.DB) error
+	Down     func(tx *gorm.DB) error
+	checksum string
+}
+
+// ID is the version and name of the migration, which is also the name of its file.
+func (m Migration) ID() string {
+	return m.Version + "_" + m.Name
+}
+
+// Checksum is the SHA-256 of the migration's file, line endings left aside.
+func (m Migration) Checksum() string {
+	return m.checksum
+}
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:32"`
+	Name      string    `gorm:"size:100;not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+var registry []Migration
+
+// register adds a migration, it panics when the version is taken or the file of the
+// migration is not named after it.
+func register(m Migration) {
+	source, err := sources.ReadFile(m.ID() + ".go")
+	if err != nil {
+		panic(fmt.Sprintf("migrations: %s must be defined in %s.go", m.ID(), m.ID()))
+	}
+	for _, other := range registry {
+		if other.Version == m.Version {
+			panic(fmt.Sprintf("migrations: %s and %s have the same version", other.ID(), m.ID()))
+		}
+	}
+
+	sum := sha256.Sum256(bytes.ReplaceAll(source, []byte("\r\n"), []byte("\n")))
+	m.checksum = hex.EncodeToString(sum[:])
+	registry = append(registry, m)
+	sort.Slice(registry, func(i, j int) bool { return registry[i].Version < registry[j].Version })
+}
+
+// All returns the known migrations, oldest first.
+func All() []Migration {
+	return append([]Migration(nil), registry...)
+}
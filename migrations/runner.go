@@ -0,0 +1,220 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Status is the state of a migration in a database.
+type Status struct {
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+	// Changed is set when the migration's file changed after it was applied
+	Changed bool `json:"changed"`
+	// Unknown is set for migrations applied to the database but missing from this build
+	Unknown bool `json:"unknown"`
+}
+
+// Up applies the pending migrations in order and returns the ones it applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	var applied []Migration
+	err := withLock(db, func() error {
+		records, err := check(db)
+		if err != nil {
+			return err
+		}
+		for _, m := range registry {
+			if _, done := records[m.Version]; done {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, Checksum: m.checksum, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %s: %w", m.ID(), err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the ones
+// it reverted.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := withLock(db, func() error {
+		records, err := check(db)
+		if err != nil {
+			return err
+		}
+		for i := len(registry) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := registry[i]
+			if _, done := records[m.Version]; !done {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Where("version = ?", m.Version).Delete(&SchemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %s: %w", m.ID(), err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Statuses lists the known migrations and the ones only the database knows about,
+// ordered by version.
+func Statuses(db *gorm.DB) ([]Status, error) {
+	records, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(registry))
+	for _, m := range registry {
+		status := Status{Version: m.Version, Name: m.Name}
+		if record, done := records[m.Version]; done {
+			status.AppliedAt = &record.AppliedAt
+			status.Changed = record.Checksum != m.checksum
+			delete(records, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range records {
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, AppliedAt: &record.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the migrations that are not applied yet.
+func Pending(db *gorm.DB) ([]Migration, error) {
+	records, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range registry {
+		if _, done := records[m.Version]; !done {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// check loads the applied migrations and refuses to go on when one of them changed
+// since or is unknown to this build.
+func check(db *gorm.DB) (map[string]SchemaMigration, error) {
+	records, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(registry))
+	for _, m := range registry {
+		known[m.Version] = true
+		if record, done := records[m.Version]; done && record.Checksum != m.checksum {
+			return nil, fmt.Errorf("migration %s was changed after it was applied, restore it and add a new migration instead", m.ID())
+		}
+	}
+	for version, record := range records {
+		if !known[version] {
+			return nil, fmt.Errorf("migration %s_%s is applied but unknown to this build, the database is newer than the code", version, record.Name)
+		}
+	}
+	return records, nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("creating the migration table: %w", err)
+	}
+	var list []SchemaMigration
+	if err := db.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	records := make(map[string]SchemaMigration, len(list))
+	for _, record := range list {
+		records[record.Version] = record
+	}
+	return records, nil
+}
+
+// Timings of the migration lock. A lock older than lockStaleAfter is left over by a
+// process that died while migrating and is taken over.
+var (
+	lockWait       = 2 * time.Minute
+	lockPoll       = time.Second
+	lockStaleAfter = 15 * time.Minute
+)
+
+// SchemaMigrationLock is the single row held by the process running migrations, so
+// instances started together don't migrate at the same time.
+type SchemaMigrationLock struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:100;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+// ErrLocked is returned when another process kept the migration lock for too long.
+var ErrLocked = errors.New("migrations are locked by another process")
+
+// withLock runs fn while holding the migration lock, waiting for it up to lockWait.
+func withLock(db *gorm.DB, fn func() error) error {
+	if err := db.AutoMigrate(&SchemaMigrationLock{}); err != nil {
+		return fmt.Errorf("creating the migration lock table: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	lock := SchemaMigrationLock{ID: 1, Owner: fmt.Sprintf("%s:%d", host, os.Getpid())}
+	// failing to insert the lock row is expected while another process holds it
+	quiet := db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)})
+	deadline := time.Now().Add(lockWait)
+	for {
+		lock.LockedAt = time.Now()
+		err := quiet.Create(&lock).Error
+		if err == nil {
+			break
+		}
+
+		var holder SchemaMigrationLock
+		if findErr := db.Where("id = ?", lock.ID).Limit(1).Find(&holder).Error; findErr != nil {
+			return findErr
+		}
+		if holder.ID == 0 {
+			// the insert failed for another reason than a held lock
+			return fmt.Errorf("taking the migration lock: %w", err)
+		}
+		stale := db.Where("id = ? AND locked_at < ?", lock.ID, time.Now().Add(-lockStaleAfter)).Delete(&SchemaMigrationLock{})
+		if stale.Error != nil {
+			return stale.Error
+		}
+		if stale.RowsAffected > 0 {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s since %s", ErrLocked, holder.Owner, holder.LockedAt.Format(time.RFC3339))
+		}
+		time.Sleep(lockPoll)
+	}
+	defer db.Where("id = ? AND owner = ?", lock.ID, lock.Owner).Delete(&SchemaMigrationLock{})
+
+	return fn()
+}
@@ -0,0 +1,173 @@
+package migrations
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openDB opens an empty SQLite database in a file of the test.
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func versions(list []Migration) []string {
+	var ids []string
+	for _, m := range list {
+		ids = append(ids, m.Version)
+	}
+	return ids
+}
+
+func TestUpDownUp(t *testing.T) {
+	db := openDB(t)
+
+	applied, err := Up(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(registry) {
+		t.Fatalf("applied %v, want all %d migrations", versions(applied), len(registry))
+	}
+	if applied, err := Up(db); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up applied %v, %v, want nothing", versions(applied), err)
+	}
+
+	reverted, err := Down(db, len(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(versions(reverted), ","); got != "0003,0002,0001" {
+		t.Errorf("reverted %s, want newest first", got)
+	}
+	if db.Migrator().HasTable("posts") || db.Migrator().HasTable("users") {
+		t.Error("the tables are left after reverting every migration")
+	}
+	if pending, err := Pending(db); err != nil || len(pending) != len(registry) {
+		t.Errorf("pending = %v, %v, want all migrations", versions(pending), err)
+	}
+
+	if applied, err := Up(db); err != nil || len(applied) != len(registry) {
+		t.Fatalf("Up after Down applied %v, %v, want all migrations", versions(applied), err)
+	}
+	if !db.Migrator().HasColumn("post_comments", "deleted_with_post") {
+		t.Error("post_comments.deleted_with_post is missing after applying again")
+	}
+}
+
+func TestDownSteps(t *testing.T) {
+	db := openDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := Down(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(versions(reverted), ","); got != "0003" {
+		t.Fatalf("reverted %s, want 0003", got)
+	}
+	if db.Migrator().HasColumn("post_comments", "deleted_with_post") {
+		t.Error("0003 down left post_comments.deleted_with_post")
+	}
+	if !db.Migrator().HasColumn("posts", "held_from") {
+		t.Error("0003 down dropped posts.held_from of 0002")
+	}
+
+	reverted, err = Down(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(versions(reverted), ","); got != "0002" {
+		t.Fatalf("reverted %s, want 0002", got)
+	}
+	for _, table := range []string{"posts", "post_comments"} {
+		if db.Migrator().HasColumn(table, "held_from") {
+			t.Errorf("0002 down left %s.held_from", table)
+		}
+	}
+	if !db.Migrator().HasTable("posts") {
+		t.Error("0002 down dropped the tables of 0001")
+	}
+
+	pending, err := Pending(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(versions(pending), ","); got != "0002,0003" {
+		t.Errorf("pending %s, want 0002,0003", got)
+	}
+}
+
+func TestChangedMigrationIsRejected(t *testing.T) {
+	db := openDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&SchemaMigration{}).Where("version = ?", "0001").Update("checksum", "edited").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Up(db); err == nil || !strings.Contains(err.Error(), "0001_initial was changed") {
+		t.Errorf("Up error = %v, want the changed migration named", err)
+	}
+	if _, err := Down(db, 1); err == nil {
+		t.Error("Down ran over a changed migration")
+	}
+	statuses, err := Statuses(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Changed || statuses[1].Changed {
+		t.Errorf("statuses = %+v, want only 0001 changed", statuses)
+	}
+}
+
+func TestLock(t *testing.T) {
+	wait, poll := lockWait, lockPoll
+	lockWait, lockPoll = 50*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { lockWait, lockPoll = wait, poll })
+	db := openDB(t)
+
+	err := withLock(db, func() error {
+		_, err := Up(db)
+		return err
+	})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("a second runner got %v, want ErrLocked", err)
+	}
+	if pending, _ := Pending(db); len(pending) != len(registry) {
+		t.Errorf("the blocked runner applied %d migrations", len(registry)-len(pending))
+	}
+
+	// the lock is released afterwards and a stale lock is taken over
+	stale := SchemaMigrationLock{ID: 1, Owner: "gone:1", LockedAt: time.Now().Add(-2 * lockStaleAfter)}
+	if err := db.Create(&stale).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Up(db); err != nil {
+		t.Fatalf("Up with a stale lock: %v", err)
+	}
+	var held int64
+	db.Model(&SchemaMigrationLock{}).Count(&held)
+	if held != 0 {
+		t.Errorf("%d locks are held after Up returned", held)
+	}
+}
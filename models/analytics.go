@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// PostView is a raw view of a post, kept until it has been rolled up into the daily
+// stats. VisitorKey identifies a signed in user, or anyone else by a keyed hash of
+// their IP and user agent.
+type PostView struct {
+	ID         uint      `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"index"`
+	PostID     uint      `gorm:"not null;index:idx_view_post_visitor"`
+	VisitorKey string    `gorm:"size:64;not null;index:idx_view_post_visitor"`
+	Referrer   string    `gorm:"size:255"`
+	RolledUp   bool      `gorm:"not null;default:false;index"`
+}
+
+// PostDailyStat is the number of views of a post on a day (YYYY-MM-DD, UTC).
+type PostDailyStat struct {
+	PostID uint   `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Day    string `json:"day" gorm:"primaryKey;size:10"`
+	Views  int64  `json:"views" gorm:"not null;default:0"`
+}
+
+// PostReferrerStat is the number of views of a post on a day that came from a site.
+type PostReferrerStat struct {
+	PostID   uint   `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Day      string `json:"day" gorm:"primaryKey;size:10"`
+	Referrer string `json:"referrer" gorm:"primaryKey;size:255"`
+	Views    int64  `json:"views" gorm:"not null;default:0"`
+}
+
+// DailyActivity is one day of a post's analytics.
+type DailyActivity struct {
+	Day       string `json:"day"`
+	Views     int64  `json:"views"`
+	Reactions int64  `json:"reactions"`
+	Comments  int64  `json:"comments"`
+}
+
+// PostAnalytics sums up how a post did over the requested period.
+type PostAnalytics struct {
+	PostID    uint            `json:"post_id"`
+	Title     string          `json:"title"`
+	Views     int64           `json:"views"`
+	Reactions int64           `json:"reactions"`
+	Comments  int64           `json:"comments"`
+	Daily     []DailyActivity `json:"daily"`
+}
+
+// ReferrerCount is the number of views that came from a referring site.
+type ReferrerCount struct {
+	Referrer string `json:"referrer"`
+	Views    int64  `json:"views"`
+}
+
+// TrendingPost is a post's place in a trending window, recomputed periodically.
+type TrendingPost struct {
+	Window     string    `json:"window" gorm:"column:time_window;primaryKey;size:5"`
+	PostID     uint      `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Rank       int       `json:"rank" gorm:"not null;index"`
+	Score      float64   `json:"score" gorm:"not null"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// RankedPost is a post of the trending list with its score.
+type RankedPost struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+	Post  Post    `json:"post"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditAppendOnly is returned when code tries to change or remove an audit entry.
+var ErrAuditAppendOnly = errors.New("audit log entries can not be changed or deleted")
+
+// AuditLog is an append-only record of a security relevant or moderation action.
+// ActorID is 0 for anonymous requests and for actions taken by the system. Before and
+// After hold JSON snapshots of the target around the change, when there is one.
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	ActorID    uint      `json:"actor_id" gorm:"index"`
+	Action     string    `json:"action" gorm:"size:50;not null;index"`
+	TargetType string    `json:"target_type" gorm:"size:20;index:idx_audit_target"`
+	TargetID   uint      `json:"target_id" gorm:"index:idx_audit_target"`
+	IP         string    `json:"ip" gorm:"size:64"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id" gorm:"size:64;index"`
+	Before     any       `json:"before,omitempty" gorm:"serializer:json;type:text" swaggertype:"object"`
+	After      any       `json:"after,omitempty" gorm:"serializer:json;type:text" swaggertype:"object"`
+}
+
+func (*AuditLog) BeforeUpdate(*gorm.DB) error { return ErrAuditAppendOnly }
+
+func (*AuditLog) BeforeDelete(*gorm.DB) error { return ErrAuditAppendOnly }
+
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6,max=100"`
+}
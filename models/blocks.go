@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Block keeps BlockedID away from UserID: they can not comment on UserID's posts,
+// mention or follow them, and the two users do not see each other's content.
+type Block struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	BlockedID uint      `json:"blocked_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Mute hides MutedID's posts and comments from UserID's feed and listings.
+type Mute struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	MutedID   uint      `json:"muted_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RelatedUser is one entry of a user's block or mute list.
+type RelatedUser struct {
+	ID        uint      `json:"id"`
+	UserName  string    `json:"user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
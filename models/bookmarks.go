@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// BookmarkCollection is a named reading list of a user.
+type BookmarkCollection struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_collection_user_name"`
+	Name      string    `json:"name" gorm:"size:100;not null;uniqueIndex:idx_collection_user_name"`
+	Count     int64     `json:"count" gorm:"->;-:migration"`
+}
+
+// Bookmark saves a post for later, optionally in one of the user's collections.
+// Bookmarks of deleted posts are kept so they come back when the post is restored,
+// and are removed when the post is purged.
+type Bookmark struct {
+	UserID       uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	PostID       uint      `json:"post_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CollectionID *uint     `json:"collection_id" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BookmarkRegister optionally names the collection to put a bookmark in, the
+// collection is created when it does not exist yet.
+type BookmarkRegister struct {
+	Collection string `json:"collection" binding:"max=100"`
+}
+
+type BookmarkCollectionRegister struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// SavedPost is one entry of a bookmark list. Post is null when the post was deleted
+// or is no longer visible to the user.
+type SavedPost struct {
+	PostID       uint      `json:"post_id"`
+	CollectionID *uint     `json:"collection_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Available    bool      `json:"available"`
+	Post         *Post     `json:"post"`
+}
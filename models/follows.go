@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Follow is an edge of the follow graph, FollowerID follows FolloweeID. The primary
+// key serves "who does X follow" lookups and the followee index serves follower lists.
+type Follow struct {
+	FollowerID uint      `json:"follower_id" gorm:"primaryKey;autoIncrement:false"`
+	FolloweeID uint      `json:"followee_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FollowUser is one entry of a followers or following list.
+type FollowUser struct {
+	ID         uint      `json:"id"`
+	UserName   string    `json:"user_name"`
+	FollowedAt time.Time `json:"followed_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// Mention links a @username written in a post caption or a comment text to the
+// mentioned user. Offset and Length are counted in Unicode code points.
+type Mention struct {
+	ID         uint   `json:"-" gorm:"primaryKey"`
+	SourceType string `json:"-" gorm:"size:20;not null;index:idx_mention_source"`
+	SourceID   uint   `json:"-" gorm:"not null;index:idx_mention_source"`
+	UserID     uint   `json:"user_id" gorm:"not null;index"`
+	UserName   string `json:"user_name"`
+	Offset     int    `json:"offset"`
+	Length     int    `json:"length"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import "time"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Status values of posts and comments, only published content shows up in listings.
+// Pending content was held back by the moderation filters and is only shown to its
+// author until a moderator approves it.
+const (
+	StatusPublished = "published"
+	StatusHidden    = "hidden"
+	StatusPending   = "pending"
+)
+
+// Hold sends an edited post to the pending queue. HeldFrom keeps the status it had
+// so approving the edit restores it, it stays empty for content that was never out
+// of the queue.
+func (post *Post) Hold() {
+	if post.Status != StatusPending {
+		post.HeldFrom = post.Status
+	}
+	post.Status = StatusPending
+}
+
+// Hold sends an edited comment to the pending queue, see Post.Hold.
+func (comment *PostComment) Hold() {
+	if comment.Status != StatusPending {
+		comment.HeldFrom = comment.Status
+	}
+	comment.Status = StatusPending
+}
+
+const (
+	ReportOpen      = "open"
+	ReportActioned  = "actioned"
+	ReportDismissed = "dismissed"
+)
+
+const (
+	ModerationHide      = "hide"
+	ModerationDelete    = "delete"
+	ModerationWarn      = "warn"
+	ModerationSuspend   = "suspend"
+	ModerationDismiss   = "dismiss"
+	ModerationAutoHide  = "auto_hide"
+	ModerationFilter    = "filter"
+	ModerationApprove   = "approve"
+	ModerationReject    = "reject"
+	ModerationBan       = "ban"
+	ModerationUnsuspend = "unsuspend"
+)
+
+// TargetUser is the target type of moderation actions taken on an account.
+const TargetUser = "user"
+
+// ReportReasons are the reason codes a report can be filed with.
+var ReportReasons = []string{"spam", "harassment", "hate", "violence", "nudity", "misinformation", "other"}
+
+// Report is a reader's flag on a post or a comment, a user can report a target once.
+type Report struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ReporterID   uint       `json:"reporter_id" gorm:"not null;uniqueIndex:idx_report_reporter_target"`
+	TargetType   string     `json:"target_type" gorm:"size:20;not null;uniqueIndex:idx_report_reporter_target;index:idx_report_target"`
+	TargetID     uint       `json:"target_id" gorm:"not null;uniqueIndex:idx_report_reporter_target;index:idx_report_target"`
+	TargetUserID uint       `json:"target_user_id" gorm:"not null;index"`
+	Reason       string     `json:"reason" gorm:"size:30;not null;index"`
+	Note         string     `json:"note"`
+	Status       string     `json:"status" gorm:"size:20;not null;default:open;index"`
+	ResolvedByID *uint      `json:"resolved_by_id"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+	ReportCount  int64      `json:"report_count,omitempty" gorm:"->;-:migration"`
+}
+
+type ReportRegister struct {
+	Reason string `json:"reason" binding:"required,oneof=spam harassment hate violence nudity misinformation other"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+// ModerationAction is the audit record of an action taken on reported content.
+// ModeratorID is 0 for actions taken automatically.
+type ModerationAction struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time `json:"created_at"`
+	ModeratorID  uint      `json:"moderator_id" gorm:"index"`
+	Action       string    `json:"action" gorm:"size:20;not null"`
+	TargetType   string    `json:"target_type" gorm:"size:20;not null;index:idx_moderation_target"`
+	TargetID     uint      `json:"target_id" gorm:"not null;index:idx_moderation_target"`
+	TargetUserID uint      `json:"target_user_id" gorm:"index"`
+	ReportID     *uint     `json:"report_id"`
+	Note         string    `json:"note"`
+}
+
+type ModerationActionRegister struct {
+	Action      string `json:"action" binding:"required,oneof=hide delete warn suspend dismiss"`
+	Note        string `json:"note" binding:"max=500"`
+	SuspendDays int    `json:"suspend_days" binding:"min=0,max=3650"`
+}
+
+// PendingContent is a post or a comment waiting in the moderators' review queue, with
+// the reason the filters held it back.
+type PendingContent struct {
+	TargetType string    `json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	PostID     uint      `json:"post_id"`
+	UserID     uint      `json:"user_id"`
+	Title      string    `json:"title,omitempty"`
+	Text       string    `json:"text"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type PendingReview struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+type RoleUpdate struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
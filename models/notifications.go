@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	NotificationComment  = "comment"
+	NotificationReply    = "reply"
+	NotificationMention  = "mention"
+	NotificationFollow   = "follow"
+	NotificationReaction = "reaction"
+	// NotificationWarning is sent by moderators and can not be turned off.
+	NotificationWarning = "warning"
+)
+
+// NotificationTypes lists every notification type a user can turn on or off.
+var NotificationTypes = []string{
+	NotificationComment,
+	NotificationReply,
+	NotificationMention,
+	NotificationFollow,
+	NotificationReaction,
+}
+
+// Notification tells UserID that ActorID did something, PostID and CommentID point
+// to the content involved when there is one.
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_notification_user_read"`
+	ActorID   uint       `json:"actor_id" gorm:"not null"`
+	ActorName string     `json:"actor_name" gorm:"->;-:migration"`
+	Type      string     `json:"type" gorm:"size:20;not null"`
+	PostID    *uint      `json:"post_id"`
+	CommentID *uint      `json:"comment_id"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index:idx_notification_user_read"`
+}
+
+// NotificationPreference stores a user's choice for one notification type, types
+// without a row are enabled.
+type NotificationPreference struct {
+	UserID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Type    string `gorm:"primaryKey;size:20"`
+	Enabled bool
+}
+
+// NotificationPreferencesUpdate maps notification types to whether they are enabled.
+type NotificationPreferencesUpdate map[string]bool
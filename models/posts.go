@@ -6,37 +6,70 @@ import (
 	"gorm.io/gorm"
 )
 
+// Target types of the things users can react to, report or mention someone in.
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
 
 type BaseModel struct {
-    ID        uint           `json:"id" gorm:"primaryKey"`
-    CreatedAt time.Time      `json:"created_at"`
-    UpdatedAt time.Time      `json:"updated_at"`
-    DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggerignore:"true"` 
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggerignore:"true"`
 }
 
 type Post struct {
 	BaseModel
-	PicAddres   string        `json:"pic_address"`
-	Title       string        `json:"title"`
-	Caption     string        `json:"caption"`
-	PostComment []PostComment `gorm:"constraint:OnDelete:CASCADE;"`
-	UserID      uint
+	PicAddres   string           `json:"pic_address"`
+	Title       string           `json:"title"`
+	Caption     string           `json:"caption"`
+	PostComment []PostComment    `gorm:"constraint:OnDelete:CASCADE;"`
+	UserID      uint             `gorm:"index"`
+	Status      string           `json:"status" gorm:"size:20;not null;default:published;index"`
+	HeldFrom    string           `json:"-" gorm:"size:20"`
+	Reactions   map[string]int64 `json:"reactions" gorm:"-"`
+	MyReaction  string           `json:"my_reaction,omitempty" gorm:"-"`
+	Bookmarked  bool             `json:"bookmarked" gorm:"-"`
+	Mentions    []Mention        `json:"mentions" gorm:"polymorphic:Source;polymorphicValue:post"`
+	Tags        []Tag            `json:"tags" gorm:"many2many:post_tags"`
 }
 
+// PostRegister is the body to create a post, when updating a post empty fields keep
+// their current value.
 type PostRegister struct {
 	PicAddres string `json:"pic_address"`
-	Title     string `json:"title" validate:"max=250"`
-	Caption   string `json:"caption" validate:"max=1000"`
+	Title     string `json:"title" binding:"max=250"`
+	Caption   string `json:"caption" binding:"max=1000"`
+	// Tags replace the tags of the post, at most MaxPostTags (10) of them, they are kept
+	// on update when left out
+	Tags []string `json:"tags" binding:"omitempty,dive,max=50"`
 }
 
+// DeletedCommentText replaces the text of a removed comment that still has replies,
+// so the thread stays readable.
+const DeletedCommentText = "[deleted]"
+
 type PostComment struct {
 	BaseModel
-	Text   string `json:"text"`
-	UserID uint   `gorm:"not null"`
-	PostID uint   `gorm:"not null"`
-	
+	Text       string           `json:"text"`
+	UserID     uint             `gorm:"not null"`
+	PostID     uint             `gorm:"not null"`
+	ParentID   *uint            `json:"parent_id" gorm:"index"`
+	Depth      int              `json:"depth"`
+	ReplyCount int              `json:"reply_count"`
+	Deleted    bool             `json:"deleted"`
+	Status     string           `json:"status" gorm:"size:20;not null;default:published;index"`
+	HeldFrom   string           `json:"-" gorm:"size:20"`
+	// DeletedWithPost marks the comments trashed together with their post, restoring
+	// the post brings back these and not the ones deleted on their own
+	DeletedWithPost bool        `json:"-" gorm:"not null;default:false"`
+	Replies    []PostComment    `json:"replies,omitempty" gorm:"-"`
+	Reactions  map[string]int64 `json:"reactions" gorm:"-"`
+	MyReaction string           `json:"my_reaction,omitempty" gorm:"-"`
+	Mentions   []Mention        `json:"mentions" gorm:"polymorphic:Source;polymorphicValue:comment"`
 }
 
 type PostCommentsRegister struct {
-	Text string `json:"text" binding:"max=250,required"`
+	Text string `json:"text" binding:"required,max=250"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ReactionTypes maps every supported reaction to the emoji clients should render.
+var ReactionTypes = map[string]string{
+	"like":  "👍",
+	"love":  "❤️",
+	"laugh": "😂",
+	"wow":   "😮",
+	"sad":   "😢",
+	"angry": "😠",
+}
+
+// Reaction is a single user's reaction on a post or a comment, a user can hold
+// only one reaction per target.
+type Reaction struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reaction_user_target"`
+	TargetType string    `json:"target_type" gorm:"size:20;not null;uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+	TargetID   uint      `json:"target_id" gorm:"not null;uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+	Type       string    `json:"type" gorm:"size:20;not null"`
+}
+
+type ReactionRegister struct {
+	Type string `json:"type" binding:"required,oneof=like love laugh wow sad angry"`
+}
+
+// Reactor is one entry of the list of users who reacted on a target.
+type Reactor struct {
+	UserID    uint      `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"strings"
+)
+
+// MaxPostTags is how many tags a post can have.
+const MaxPostTags = 10
+
+// Tag is a topic posts are filed under, shared by every post that uses its name.
+type Tag struct {
+	ID   uint   `json:"-" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:50;not null;uniqueIndex"`
+}
+
+// TagName normalizes a tag as typed by a user: lower case, without surrounding spaces
+// or a leading #.
+func TagName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
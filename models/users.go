@@ -1,23 +1,48 @@
 package models
 
 import (
-	
+	"time"
 )
 
 type User struct {
 	BaseModel
-	UserName  string `gorm:"unique" json:"user_name"`
-	Email     string `gorm:"unique" json:"email"`
+	UserName  string `gorm:"size:100;unique" json:"user_name"`
+	Email     string `gorm:"size:100;unique" json:"email"`
 	Password  string `json:"-"`
+	Role      string `gorm:"size:20;not null;default:user" json:"role"`
+	SuspendedUntil   *time.Time `json:"suspended_until"`
+	SuspensionReason string     `json:"suspension_reason"`
+	Banned           bool       `gorm:"not null;default:false;index" json:"banned"`
 	UserProfile  UserProfile  `gorm:"constraint:OnDelete:CASCADE;"`
 	Posts     []Post         `gorm:"constraint:OnDelete:CASCADE;"`
 	PostComment  []PostComment
 }
 
+// Suspended reports whether the user is banned or suspended at the given time.
+func (user User) Suspended(now time.Time) bool {
+	return user.Banned || (user.SuspendedUntil != nil && now.Before(*user.SuspendedUntil))
+}
+
+// SuspensionRegister suspends a user for Days days, or for good when Ban is set.
+type SuspensionRegister struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+	Days   int    `json:"days" binding:"min=0,max=3650"`
+	Ban    bool   `json:"ban"`
+}
+
+// Suspension is a suspended or banned user as listed to the admins.
+type Suspension struct {
+	ID               uint       `json:"id"`
+	UserName         string     `json:"user_name"`
+	Banned           bool       `json:"banned"`
+	SuspendedUntil   *time.Time `json:"suspended_until"`
+	SuspensionReason string     `json:"suspension_reason"`
+}
+
 type RegisterUsers struct {
-	UserName string `json:"user_name" binding:"min=3,max=100,required"`
-	Email    string `json:"email" binding:"min=3,max=100,required,email"`
-	Password string `json:"password" binding:"min=6,max=100,required"`
+	UserName string `json:"user_name" binding:"required,min=3,max=100"`
+	Email    string `json:"email" binding:"required,min=3,max=100,email"`
+	Password string `json:"password" binding:"required,min=6,max=100"`
 }
 
 
@@ -0,0 +1,114 @@
+// Package moderation screens posts and comments before they are saved. A chain of
+// filters looks at the new content and the first filter that flags it sends it to the
+// moderators' pending queue instead of publishing it.
+package moderation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/config"
+)
+
+// Content is a post or a comment about to be saved.
+type Content struct {
+	Type     string // models.TargetPost or models.TargetComment
+	ID       uint   // set when an existing post or comment is edited
+	AuthorID uint
+	Title    string // posts only
+	Text     string // caption of a post, text of a comment
+}
+
+// fullText is the title and the text of the content.
+func (content Content) fullText() string {
+	if content.Title == "" {
+		return content.Text
+	}
+	return content.Title + "\n" + content.Text
+}
+
+// Verdict tells why a filter flagged the content.
+type Verdict struct {
+	Filter string `json:"filter"`
+	Reason string `json:"reason"`
+}
+
+// Filter inspects content before it is saved. Check returns a non nil verdict when the
+// content should wait for a moderator.
+type Filter interface {
+	Name() string
+	Check(content Content) (*Verdict, error)
+}
+
+// Chain runs its filters in order and stops at the first one that flags the content.
+type Chain []Filter
+
+// Check returns the verdict of the first filter that flags the content, or nil.
+func (chain Chain) Check(content Content) (*Verdict, error) {
+	for _, filter := range chain {
+		verdict, err := filter.Check(content)
+		if err != nil || verdict != nil {
+			return verdict, err
+		}
+	}
+	return nil, nil
+}
+
+var (
+	defaultChain, _ = builtins(config.Default().Moderation)
+	extra           Chain
+	mu              sync.RWMutex
+)
+
+// Configure replaces the built in filters of the default chain with the ones cfg
+// turns on. An error is returned when the blocklist file can not be loaded.
+func Configure(cfg config.Moderation) error {
+	chain, err := builtins(cfg)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultChain = chain
+	return nil
+}
+
+// Use appends filters to the default chain, for checks that do not ship with the API.
+func Use(filters ...Filter) {
+	mu.Lock()
+	defer mu.Unlock()
+	extra = append(extra, filters...)
+}
+
+// Default returns the built in filters set up by Configure followed by the filters
+// added with Use.
+func Default() Chain {
+	mu.RLock()
+	defer mu.RUnlock()
+	chain := make(Chain, 0, len(defaultChain)+len(extra))
+	return append(append(chain, defaultChain...), extra...)
+}
+
+// builtins sets up the filters that ship with the API. A filter whose setting is 0 or
+// empty is left out.
+func builtins(cfg config.Moderation) (Chain, error) {
+	var chain Chain
+	if cfg.BlocklistFile != "" {
+		blocklist, err := LoadBlocklist(cfg.BlocklistFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading the moderation blocklist %s: %w", cfg.BlocklistFile, err)
+		}
+		chain = append(chain, blocklist)
+	}
+	if cfg.MaxLinks > 0 {
+		chain = append(chain, LinkLimit{Max: cfg.MaxLinks})
+	}
+	if cfg.DuplicateWindow.Duration > 0 {
+		chain = append(chain, Duplicates{Window: cfg.DuplicateWindow.Duration})
+	}
+	if cfg.NewAccountAge.Duration > 0 && cfg.NewAccountMaxPerHour > 0 {
+		chain = append(chain, NewAccountLimit{Age: cfg.NewAccountAge.Duration, Max: cfg.NewAccountMaxPerHour, Period: time.Hour})
+	}
+	return chain, nil
+}
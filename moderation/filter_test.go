@@ -0,0 +1,43 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/config"
+)
+
+func TestBuiltinsLeaveOutFiltersSetToZero(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *config.Moderation)
+		without string
+	}{
+		{"no links allowed", func(cfg *config.Moderation) { cfg.MaxLinks = 0 }, "links"},
+		{"no duplicate window", func(cfg *config.Moderation) { cfg.DuplicateWindow.Duration = 0 }, "duplicate"},
+		{"no new account age", func(cfg *config.Moderation) { cfg.NewAccountAge.Duration = 0 }, "new_account"},
+		{"no new account limit", func(cfg *config.Moderation) { cfg.NewAccountMaxPerHour = 0 }, "new_account"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default().Moderation
+			tt.change(&cfg)
+			chain, err := builtins(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, filter := range chain {
+				if filter.Name() == tt.without {
+					t.Errorf("the %s filter is on", tt.without)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigureRejectsMissingBlocklist(t *testing.T) {
+	cfg := config.Default().Moderation
+	cfg.BlocklistFile = t.TempDir() + "/missing.txt"
+	if err := Configure(cfg); err == nil {
+		t.Error("a blocklist file that does not exist was accepted")
+	}
+}
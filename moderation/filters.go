@@ -0,0 +1,156 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+)
+
+// Blocklist flags content containing a blocked word or matching a blocked pattern.
+// Words match case insensitively on word boundaries.
+type Blocklist struct {
+	Patterns []*regexp.Regexp
+}
+
+// LoadBlocklist reads a blocklist file with one entry per line. An entry written as
+// /pattern/ is a regular expression, any other entry is a word. Empty lines and lines
+// starting with # are skipped.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entries = append(entries, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewBlocklist(entries)
+}
+
+// NewBlocklist builds a blocklist from words and /pattern/ entries.
+func NewBlocklist(entries []string) (*Blocklist, error) {
+	blocklist := &Blocklist{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		expr := `(?i)\b` + regexp.QuoteMeta(entry) + `\b`
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			expr = "(?i)" + entry[1:len(entry)-1]
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("blocklist entry %q: %w", entry, err)
+		}
+		blocklist.Patterns = append(blocklist.Patterns, pattern)
+	}
+	return blocklist, nil
+}
+
+func (Blocklist) Name() string { return "blocklist" }
+
+func (blocklist Blocklist) Check(content Content) (*Verdict, error) {
+	for _, pattern := range blocklist.Patterns {
+		if match := pattern.FindString(content.fullText()); match != "" {
+			return &Verdict{Filter: blocklist.Name(), Reason: fmt.Sprintf("contains blocked text %q", match)}, nil
+		}
+	}
+	return nil, nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkLimit flags content with more than Max links.
+type LinkLimit struct {
+	Max int
+}
+
+func (LinkLimit) Name() string { return "links" }
+
+func (limit LinkLimit) Check(content Content) (*Verdict, error) {
+	if links := len(linkPattern.FindAllString(content.fullText(), -1)); links > limit.Max {
+		return &Verdict{Filter: limit.Name(), Reason: fmt.Sprintf("contains %d links, at most %d are allowed", links, limit.Max)}, nil
+	}
+	return nil, nil
+}
+
+// Duplicates flags content whose author posted the same text within Window.
+type Duplicates struct {
+	Window time.Duration
+}
+
+func (Duplicates) Name() string { return "duplicate" }
+
+func (duplicates Duplicates) Check(content Content) (*Verdict, error) {
+	if strings.TrimSpace(content.fullText()) == "" {
+		return nil, nil
+	}
+
+	since := time.Now().Add(-duplicates.Window)
+	var count int64
+	var err error
+	if content.Type == models.TargetComment {
+		err = db.DB.Model(&models.PostComment{}).
+			Where("user_id = ? AND text = ? AND created_at > ? AND id <> ?", content.AuthorID, content.Text, since, content.ID).
+			Count(&count).Error
+	} else {
+		err = db.DB.Model(&models.Post{}).
+			Where("user_id = ? AND title = ? AND caption = ? AND created_at > ? AND id <> ?", content.AuthorID, content.Title, content.Text, since, content.ID).
+			Count(&count).Error
+	}
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	return &Verdict{Filter: duplicates.Name(), Reason: "same text was posted recently"}, nil
+}
+
+// NewAccountLimit flags content of accounts younger than Age once they posted Max
+// posts and comments within Period.
+type NewAccountLimit struct {
+	Age    time.Duration
+	Max    int
+	Period time.Duration
+}
+
+func (NewAccountLimit) Name() string { return "new_account" }
+
+func (limit NewAccountLimit) Check(content Content) (*Verdict, error) {
+	if content.ID != 0 {
+		return nil, nil
+	}
+
+	var author models.User
+	if err := db.DB.Select("id", "created_at").Where("id = ?", content.AuthorID).First(&author).Error; err != nil {
+		return nil, err
+	}
+	if time.Since(author.CreatedAt) >= limit.Age {
+		return nil, nil
+	}
+
+	since := time.Now().Add(-limit.Period)
+	var posts, comments int64
+	if err := db.DB.Model(&models.Post{}).Where("user_id = ? AND created_at > ?", content.AuthorID, since).Count(&posts).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Model(&models.PostComment{}).Where("user_id = ? AND created_at > ?", content.AuthorID, since).Count(&comments).Error; err != nil {
+		return nil, err
+	}
+	if posts+comments < int64(limit.Max) {
+		return nil, nil
+	}
+	return &Verdict{Filter: limit.Name(), Reason: fmt.Sprintf("new accounts can post %d times per %s", limit.Max, limit.Period)}, nil
+}
@@ -0,0 +1,78 @@
+// Package notifications creates in-app notifications for users, honoring the
+// notification types they turned off.
+package notifications
+
+import (
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/stream"
+)
+
+// Event is the type of the stream events carrying new notifications.
+const Event = "notification"
+
+// Notify stores the notification and pushes it to the user's stream, unless the user
+// is notifying themselves, has turned off this type of notification or has blocked or
+// muted the actor.
+func Notify(notification models.Notification) error {
+	if notification.UserID == 0 || notification.UserID == notification.ActorID {
+		return nil
+	}
+
+	enabled, err := Enabled(notification.UserID, notification.Type)
+	if err != nil || !enabled {
+		return err
+	}
+
+	silenced, err := silenced(notification.UserID, notification.ActorID)
+	if err != nil || silenced {
+		return err
+	}
+
+	if err := db.DB.Create(&notification).Error; err != nil {
+		return err
+	}
+
+	db.DB.Model(&models.User{}).Select("user_name").Where("id = ?", notification.ActorID).Scan(&notification.ActorName)
+	stream.Default.Publish(stream.UserTopic(notification.UserID), Event, notification)
+	return nil
+}
+
+// Enabled reports whether the user wants notifications of the given type.
+func Enabled(userID uint, notificationType string) (bool, error) {
+	var preferences []models.NotificationPreference
+	err := db.DB.Where("user_id = ? AND type = ?", userID, notificationType).Limit(1).Find(&preferences).Error
+	if err != nil {
+		return false, err
+	}
+	return len(preferences) == 0 || preferences[0].Enabled, nil
+}
+
+// Preferences returns the enabled state of every notification type for the user.
+func Preferences(userID uint) (map[string]bool, error) {
+	preferences := make(map[string]bool, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		preferences[notificationType] = true
+	}
+
+	var stored []models.NotificationPreference
+	if err := db.DB.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	for _, preference := range stored {
+		preferences[preference.Type] = preference.Enabled
+	}
+	return preferences, nil
+}
+
+// silenced reports whether userID blocked or muted actorID.
+func silenced(userID, actorID uint) (bool, error) {
+	var blocks, mutes int64
+	if err := db.DB.Model(&models.Block{}).Where("user_id = ? AND blocked_id = ?", userID, actorID).Count(&blocks).Error; err != nil {
+		return false, err
+	}
+	if err := db.DB.Model(&models.Mute{}).Where("user_id = ? AND muted_id = ?", userID, actorID).Count(&mutes).Error; err != nil {
+		return false, err
+	}
+	return blocks+mutes > 0, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+)
+
+type gormComments struct {
+	db *gorm.DB
+}
+
+func (r gormComments) Get(postID, commentID uint) (models.PostComment, error) {
+	var comment models.PostComment
+	err := r.db.Where("id = ? AND post_id = ?", commentID, postID).First(&comment).Error
+	return comment, translate(err)
+}
+
+func (r gormComments) Visible(viewerID, postID, commentID uint) (models.PostComment, error) {
+	var comment models.PostComment
+	err := r.db.Scopes(VisibleTo(viewerID)).Preload("Mentions").
+		Where("id = ? AND post_id = ?", commentID, postID).First(&comment).Error
+	return comment, translate(err)
+}
+
+func (r gormComments) Replies(viewerID uint, parentIDs []uint) ([]models.PostComment, error) {
+	var replies []models.PostComment
+	err := r.db.Scopes(VisibleTo(viewerID)).Preload("Mentions").
+		Where("parent_id IN ?", parentIDs).Order("created_at").Find(&replies).Error
+	return replies, err
+}
+
+func (r gormComments) Create(comment *models.PostComment) error {
+	if comment.ParentID == nil {
+		return r.db.Create(comment).Error
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PostComment{}).Where("id = ?", *comment.ParentID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error
+	})
+}
+
+func (r gormComments) Update(comment *models.PostComment) error {
+	return r.db.Model(comment).Updates(map[string]any{"text": comment.Text, "status": comment.Status, "held_from": comment.HeldFrom}).Error
+}
+
+func (r gormComments) Delete(comment *models.PostComment) (bool, error) {
+	if comment.ReplyCount > 0 {
+		err := r.db.Model(comment).Updates(map[string]any{"text": models.DeletedCommentText, "deleted": true}).Error
+		return true, err
+	}
+	return false, r.db.Transaction(func(tx *gorm.DB) error { return removeComment(tx, comment) })
+}
+
+// removeComment deletes a comment that has no replies left and keeps the parent's
+// reply count in sync. A "[deleted]" placeholder parent that loses its last reply
+// is removed as well.
+func removeComment(tx *gorm.DB, comment *models.PostComment) error {
+	if err := tx.Delete(comment).Error; err != nil {
+		return err
+	}
+	if comment.ParentID == nil {
+		return nil
+	}
+
+	var parent models.PostComment
+	if err := tx.Where("id = ?", *comment.ParentID).First(&parent).Error; err != nil {
+		return err
+	}
+	parent.ReplyCount--
+	if err := tx.Model(&parent).UpdateColumn("reply_count", parent.ReplyCount).Error; err != nil {
+		return err
+	}
+	if parent.Deleted && parent.ReplyCount <= 0 {
+		return removeComment(tx, &parent)
+	}
+	return nil
+}
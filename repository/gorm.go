@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewStore returns a Store that keeps the records in db.
+func NewStore(db *gorm.DB) Store {
+	return gormStore{db: db}
+}
+
+func (s gormStore) Posts() PostRepository       { return gormPosts{db: s.db} }
+func (s gormStore) Comments() CommentRepository { return gormComments{db: s.db} }
+func (s gormStore) Users() UserRepository       { return gormUsers{db: s.db} }
+
+func (s gormStore) Transaction(fn func(Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(gormStore{db: tx})
+	})
+}
+
+// translate maps the GORM errors the services act on to the errors of this package.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	}
+	return err
+}
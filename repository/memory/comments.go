@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+)
+
+type comments struct {
+	store *Store
+}
+
+func (d *data) comment(postID, commentID uint) (models.PostComment, error) {
+	comment, ok := d.comments[commentID]
+	if !ok || comment.PostID != postID || comment.DeletedAt.Valid {
+		return models.PostComment{}, repository.ErrNotFound
+	}
+	return comment, nil
+}
+
+func (r comments) Get(postID, commentID uint) (models.PostComment, error) {
+	var found models.PostComment
+	err := r.store.update(func(d *data) error {
+		var err error
+		found, err = d.comment(postID, commentID)
+		return err
+	})
+	return found, err
+}
+
+func (r comments) Visible(viewerID, postID, commentID uint) (models.PostComment, error) {
+	var found models.PostComment
+	err := r.store.update(func(d *data) error {
+		comment, err := d.comment(postID, commentID)
+		if err != nil || !d.visible(viewerID, comment.UserID, comment.Status) {
+			return repository.ErrNotFound
+		}
+		found = comment
+		return nil
+	})
+	return found, err
+}
+
+func (r comments) Replies(viewerID uint, parentIDs []uint) ([]models.PostComment, error) {
+	var found []models.PostComment
+	err := r.store.update(func(d *data) error {
+		for _, comment := range d.comments {
+			if comment.ParentID == nil || !slices.Contains(parentIDs, *comment.ParentID) {
+				continue
+			}
+			if !comment.DeletedAt.Valid && d.visible(viewerID, comment.UserID, comment.Status) {
+				found = append(found, comment)
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(found, func(a, b models.PostComment) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return found, err
+}
+
+func (r comments) Create(comment *models.PostComment) error {
+	return r.store.update(func(d *data) error {
+		if comment.ParentID != nil {
+			parent, ok := d.comments[*comment.ParentID]
+			if !ok {
+				return repository.ErrNotFound
+			}
+			parent.ReplyCount++
+			d.comments[parent.ID] = parent
+		}
+		stamp(&comment.BaseModel, d.nextID())
+		if comment.Status == "" {
+			comment.Status = models.StatusPublished
+		}
+		d.comments[comment.ID] = *comment
+		return nil
+	})
+}
+
+func (r comments) Update(comment *models.PostComment) error {
+	return r.store.update(func(d *data) error {
+		stored, ok := d.comments[comment.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		stored.Text = comment.Text
+		stored.Status = comment.Status
+		stored.HeldFrom = comment.HeldFrom
+		stored.UpdatedAt = time.Now()
+		d.comments[comment.ID] = stored
+		return nil
+	})
+}
+
+func (r comments) Delete(comment *models.PostComment) (bool, error) {
+	placeholder := comment.ReplyCount > 0
+	err := r.store.update(func(d *data) error {
+		stored, ok := d.comments[comment.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		if placeholder {
+			stored.Text = models.DeletedCommentText
+			stored.Deleted = true
+			d.comments[comment.ID] = stored
+			return nil
+		}
+		d.remove(stored)
+		return nil
+	})
+	return placeholder, err
+}
+
+// remove deletes a comment and updates its parent like the GORM store does.
+func (d *data) remove(comment models.PostComment) {
+	delete(d.comments, comment.ID)
+	if comment.ParentID == nil {
+		return
+	}
+	parent, ok := d.comments[*comment.ParentID]
+	if !ok {
+		return
+	}
+	parent.ReplyCount--
+	d.comments[parent.ID] = parent
+	if parent.Deleted && parent.ReplyCount <= 0 {
+		d.remove(parent)
+	}
+}
@@ -0,0 +1,140 @@
+// Package memory is an in-memory repository.Store for tests. It follows the rules of
+// the GORM store that the services depend on: visibility of pending content and of
+// blocked and muted users, the trash, reply counts and unique user names and emails.
+package memory
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+)
+
+// pair is a relation between two users, such as a block from the first to the second.
+type pair [2]uint
+
+type data struct {
+	lastID   uint
+	posts    map[uint]models.Post
+	comments map[uint]models.PostComment
+	tags     map[string]models.Tag
+	users    map[uint]models.User
+	profiles map[uint]models.UserProfile
+	blocks   map[pair]bool
+	mutes    map[pair]bool
+	follows  map[pair]bool
+}
+
+func (d *data) clone() *data {
+	return &data{
+		lastID:   d.lastID,
+		posts:    maps.Clone(d.posts),
+		comments: maps.Clone(d.comments),
+		tags:     maps.Clone(d.tags),
+		users:    maps.Clone(d.users),
+		profiles: maps.Clone(d.profiles),
+		blocks:   maps.Clone(d.blocks),
+		mutes:    maps.Clone(d.mutes),
+		follows:  maps.Clone(d.follows),
+	}
+}
+
+// nextID hands out IDs from one sequence for every kind of record.
+func (d *data) nextID() uint {
+	d.lastID++
+	return d.lastID
+}
+
+var _ repository.Store = (*Store)(nil)
+
+// Store is a repository.Store that keeps everything in maps. The zero value is not
+// usable, create one with New.
+type Store struct {
+	mu   sync.Mutex
+	data *data
+}
+
+// New returns an empty Store.
+func New() *Store {
+	return &Store{data: &data{
+		posts:    make(map[uint]models.Post),
+		comments: make(map[uint]models.PostComment),
+		tags:     make(map[string]models.Tag),
+		users:    make(map[uint]models.User),
+		profiles: make(map[uint]models.UserProfile),
+		blocks:   make(map[pair]bool),
+		mutes:    make(map[pair]bool),
+		follows:  make(map[pair]bool),
+	}}
+}
+
+func (s *Store) Posts() repository.PostRepository       { return posts{s} }
+func (s *Store) Comments() repository.CommentRepository { return comments{s} }
+func (s *Store) Users() repository.UserRepository       { return users{s} }
+
+// Transaction runs fn on the store itself and puts back the records as they were
+// before when fn returns an error.
+func (s *Store) Transaction(fn func(repository.Store) error) error {
+	s.mu.Lock()
+	before := s.data.clone()
+	s.mu.Unlock()
+
+	err := fn(s)
+	if err != nil {
+		s.mu.Lock()
+		s.data = before
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// Block makes ownerID block blockedID.
+func (s *Store) Block(ownerID, blockedID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.blocks[pair{ownerID, blockedID}] = true
+}
+
+// Mute makes ownerID mute mutedID.
+func (s *Store) Mute(ownerID, mutedID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.mutes[pair{ownerID, mutedID}] = true
+}
+
+// Follow makes followerID follow followeeID.
+func (s *Store) Follow(followerID, followeeID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.follows[pair{followerID, followeeID}] = true
+}
+
+// update runs fn with the lock held.
+func (s *Store) update(fn func(d *data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+// visible mirrors repository.VisibleTo for content with the given author and status.
+func (d *data) visible(viewerID, authorID uint, status string) bool {
+	if repository.HideBannedContent() && d.users[authorID].Banned {
+		return false
+	}
+	if viewerID == 0 {
+		return status == models.StatusPublished
+	}
+	if status != models.StatusPublished && !(status == models.StatusPending && authorID == viewerID) {
+		return false
+	}
+	return !d.blocks[pair{viewerID, authorID}] && !d.blocks[pair{authorID, viewerID}] && !d.mutes[pair{viewerID, authorID}]
+}
+
+func stamp(model *models.BaseModel, id uint) {
+	now := time.Now()
+	model.ID = id
+	model.CreatedAt = now
+	model.UpdatedAt = now
+}
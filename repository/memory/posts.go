@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+	"gorm.io/gorm"
+)
+
+type posts struct {
+	store *Store
+}
+
+// withContent returns the post with the comments visible to the viewer.
+func (d *data) withContent(viewerID uint, post models.Post) models.Post {
+	post.PostComment = nil
+	for _, id := range slices.Sorted(maps.Keys(d.comments)) {
+		comment := d.comments[id]
+		if comment.PostID == post.ID && !comment.DeletedAt.Valid && d.visible(viewerID, comment.UserID, comment.Status) {
+			post.PostComment = append(post.PostComment, comment)
+		}
+	}
+	post.Tags = slices.Clone(post.Tags)
+	return post
+}
+
+func (r posts) List(filter repository.PostFilter) ([]models.Post, error) {
+	var found []models.Post
+	err := r.store.update(func(d *data) error {
+		tag := models.TagName(filter.Tag)
+		for _, id := range slices.Sorted(maps.Keys(d.posts)) {
+			post := d.posts[id]
+			if post.DeletedAt.Valid || !d.visible(filter.ViewerID, post.UserID, post.Status) {
+				continue
+			}
+			if filter.Tag != "" && !slices.ContainsFunc(post.Tags, func(t models.Tag) bool { return t.Name == tag }) {
+				continue
+			}
+			found = append(found, d.withContent(filter.ViewerID, post))
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r posts) Visible(viewerID, postID uint) (models.Post, error) {
+	var found models.Post
+	err := r.store.update(func(d *data) error {
+		post, ok := d.posts[postID]
+		if !ok || post.DeletedAt.Valid || !d.visible(viewerID, post.UserID, post.Status) {
+			return repository.ErrNotFound
+		}
+		found = d.withContent(viewerID, post)
+		return nil
+	})
+	return found, err
+}
+
+func (r posts) Get(postID uint) (models.Post, error) {
+	var found models.Post
+	err := r.store.update(func(d *data) error {
+		post, ok := d.posts[postID]
+		if !ok || post.DeletedAt.Valid {
+			return repository.ErrNotFound
+		}
+		post.Tags = nil
+		found = post
+		return nil
+	})
+	return found, err
+}
+
+func (r posts) Create(post *models.Post) error {
+	return r.store.update(func(d *data) error {
+		stamp(&post.BaseModel, d.nextID())
+		if post.Status == "" {
+			post.Status = models.StatusPublished
+		}
+		stored := *post
+		stored.Tags = slices.Clone(post.Tags)
+		d.posts[post.ID] = stored
+		return nil
+	})
+}
+
+func (r posts) Update(post *models.Post) error {
+	return r.store.update(func(d *data) error {
+		stored, ok := d.posts[post.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		post.UpdatedAt = time.Now()
+		tags := stored.Tags
+		stored = *post
+		stored.Tags = tags
+		stored.PostComment = nil
+		d.posts[post.ID] = stored
+		return nil
+	})
+}
+
+func (r posts) Tags(names []string) ([]models.Tag, error) {
+	var found []models.Tag
+	err := r.store.update(func(d *data) error {
+		found = make([]models.Tag, 0, len(names))
+		for _, name := range names {
+			name = models.TagName(name)
+			if name == "" || slices.ContainsFunc(found, func(t models.Tag) bool { return t.Name == name }) {
+				continue
+			}
+			tag, ok := d.tags[name]
+			if !ok {
+				tag = models.Tag{ID: d.nextID(), Name: name}
+				d.tags[name] = tag
+			}
+			found = append(found, tag)
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (r posts) ReplaceTags(post *models.Post, tags []models.Tag) error {
+	return r.store.update(func(d *data) error {
+		stored, ok := d.posts[post.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		stored.Tags = slices.Clone(tags)
+		d.posts[post.ID] = stored
+		post.Tags = slices.Clone(tags)
+		return nil
+	})
+}
+
+func (r posts) LoadTags(post *models.Post) error {
+	return r.store.update(func(d *data) error {
+		post.Tags = slices.Clone(d.posts[post.ID].Tags)
+		return nil
+	})
+}
+
+func (r posts) Trash(post *models.Post) error {
+	return r.store.update(func(d *data) error {
+		deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+		stored, ok := d.posts[post.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		stored.DeletedAt = deletedAt
+		d.posts[post.ID] = stored
+		for id, comment := range d.comments {
+			if comment.PostID == post.ID && !comment.DeletedAt.Valid {
+				comment.DeletedAt = deletedAt
+				comment.DeletedWithPost = true
+				d.comments[id] = comment
+			}
+		}
+		post.DeletedAt = deletedAt
+		return nil
+	})
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+)
+
+type users struct {
+	store *Store
+}
+
+func (r users) Create(user *models.User) error {
+	return r.store.update(func(d *data) error {
+		for _, other := range d.users {
+			if other.UserName == user.UserName || other.Email == user.Email {
+				return repository.ErrDuplicate
+			}
+		}
+		stamp(&user.BaseModel, d.nextID())
+		if user.Role == "" {
+			user.Role = models.RoleUser
+		}
+		d.users[user.ID] = *user
+		return nil
+	})
+}
+
+// find returns the first user match accepts.
+func (r users) find(match func(models.User) bool) (models.User, error) {
+	var found models.User
+	err := r.store.update(func(d *data) error {
+		for _, user := range d.users {
+			if match(user) {
+				found = user
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+	return found, err
+}
+
+func (r users) Get(userID uint) (models.User, error) {
+	return r.find(func(user models.User) bool { return user.ID == userID })
+}
+
+func (r users) ByName(userName string) (models.User, error) {
+	return r.find(func(user models.User) bool { return user.UserName == userName })
+}
+
+func (r users) ByCredential(credential string) (models.User, error) {
+	user, err := r.find(func(user models.User) bool { return user.Email == credential })
+	if errors.Is(err, repository.ErrNotFound) {
+		return r.ByName(credential)
+	}
+	return user, err
+}
+
+func (r users) UpdatePassword(user *models.User, hash string) error {
+	return r.store.update(func(d *data) error {
+		stored, ok := d.users[user.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		stored.Password = hash
+		d.users[user.ID] = stored
+		user.Password = hash
+		return nil
+	})
+}
+
+func (r users) Profile(userID uint) (models.UserProfile, error) {
+	var found models.UserProfile
+	err := r.store.update(func(d *data) error {
+		profile, ok := d.profiles[userID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		found = profile
+		return nil
+	})
+	return found, err
+}
+
+func (r users) SaveProfile(profile *models.UserProfile) error {
+	return r.store.update(func(d *data) error {
+		if profile.ID == 0 {
+			stamp(&profile.BaseModel, d.nextID())
+		} else {
+			profile.UpdatedAt = time.Now()
+		}
+		d.profiles[profile.UserID] = *profile
+		return nil
+	})
+}
+
+func (r users) Blocked(ownerID, actorID uint) (bool, error) {
+	var blocked bool
+	err := r.store.update(func(d *data) error {
+		blocked = d.blocks[pair{ownerID, actorID}]
+		return nil
+	})
+	return blocked, err
+}
+
+func (r users) Relation(viewerID, userID uint) (repository.Relation, error) {
+	var relation repository.Relation
+	err := r.store.update(func(d *data) error {
+		relation = repository.Relation{
+			Following: d.follows[pair{viewerID, userID}],
+			Blocking:  d.blocks[pair{viewerID, userID}],
+			Muting:    d.mutes[pair{viewerID, userID}],
+		}
+		return nil
+	})
+	return relation, err
+}
+
+func (r users) FollowCounts(userID uint) (int64, int64, error) {
+	var followers, following int64
+	err := r.store.update(func(d *data) error {
+		for follow := range d.follows {
+			if follow[1] == userID {
+				followers++
+			}
+			if follow[0] == userID {
+				following++
+			}
+		}
+		return nil
+	})
+	return followers, following, err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormPosts struct {
+	db *gorm.DB
+}
+
+// withContent loads the associations a post is shown with.
+func withContent(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Scopes(VisibleTo(viewerID)).
+			Preload("Mentions").
+			Preload("Tags").
+			Preload("PostComment", VisibleTo(viewerID)).
+			Preload("PostComment.Mentions")
+	}
+}
+
+func (r gormPosts) List(filter PostFilter) ([]models.Post, error) {
+	query := r.db.Scopes(withContent(filter.ViewerID))
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", TaggedWith(r.db, filter.Tag))
+	}
+	var posts []models.Post
+	err := query.Find(&posts).Error
+	return posts, err
+}
+
+func (r gormPosts) Visible(viewerID, postID uint) (models.Post, error) {
+	var post models.Post
+	err := r.db.Scopes(withContent(viewerID)).Where("id = ?", postID).First(&post).Error
+	return post, translate(err)
+}
+
+func (r gormPosts) Get(postID uint) (models.Post, error) {
+	var post models.Post
+	err := r.db.Where("id = ?", postID).First(&post).Error
+	return post, translate(err)
+}
+
+func (r gormPosts) Create(post *models.Post) error {
+	return r.db.Create(post).Error
+}
+
+func (r gormPosts) Update(post *models.Post) error {
+	return r.db.Omit(clause.Associations).Save(post).Error
+}
+
+func (r gormPosts) Tags(names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = models.TagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, models.Tag{Name: name})
+	}
+	if len(tags) == 0 {
+		return tags, nil
+	}
+
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+	// rows that already existed come back without an ID
+	var found []models.Tag
+	if err := r.db.Where("name IN ?", slices.Collect(maps.Keys(seen))).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (r gormPosts) ReplaceTags(post *models.Post, tags []models.Tag) error {
+	return r.db.Model(post).Association("Tags").Replace(tags)
+}
+
+func (r gormPosts) LoadTags(post *models.Post) error {
+	return r.db.Model(post).Association("Tags").Find(&post.Tags)
+}
+
+func (r gormPosts) Trash(post *models.Post) error {
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(post).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PostComment{}).Where("post_id = ?", post.ID).
+			Updates(map[string]any{"deleted_at": deletedAt, "deleted_with_post": true}).Error
+	})
+	if err == nil {
+		post.DeletedAt = deletedAt
+	}
+	return err
+}
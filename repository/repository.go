@@ -0,0 +1,104 @@
+// Package repository keeps the queries of posts, comments and users behind interfaces,
+// so the services that hold the business rules can run against GORM or, in tests,
+// against the in-memory store of the memory package.
+package repository
+
+import (
+	"errors"
+
+	"github.com/dayiamin/gin_blog_api/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist or is not
+	// visible to the viewer.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a record breaks a unique constraint.
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// Store hands out the repositories. Transaction runs fn with a Store whose
+// repositories all share one transaction, which is committed when fn returns nil.
+type Store interface {
+	Posts() PostRepository
+	Comments() CommentRepository
+	Users() UserRepository
+	Transaction(fn func(Store) error) error
+}
+
+// PostFilter selects the posts returned by PostRepository.List.
+type PostFilter struct {
+	// ViewerID is the user the posts are listed for, 0 for anonymous visitors.
+	ViewerID uint
+	// Tag keeps only the posts filed under this tag when set.
+	Tag string
+}
+
+type PostRepository interface {
+	// List returns the posts visible to the viewer with their visible comments,
+	// mentions and tags.
+	List(filter PostFilter) ([]models.Post, error)
+	// Visible returns one post visible to the viewer with its visible comments,
+	// mentions and tags.
+	Visible(viewerID, postID uint) (models.Post, error)
+	// Get returns a post whatever its status, without its associations.
+	Get(postID uint) (models.Post, error)
+	// Create saves a new post together with its tags.
+	Create(post *models.Post) error
+	// Update saves the columns of an existing post, its tags are left alone.
+	Update(post *models.Post) error
+	// Tags returns the tags with the given names, creating the missing ones. Names
+	// are normalized with models.TagName and duplicates are dropped.
+	Tags(names []string) ([]models.Tag, error)
+	// ReplaceTags files the post under tags only.
+	ReplaceTags(post *models.Post, tags []models.Tag) error
+	// LoadTags fills post.Tags.
+	LoadTags(post *models.Post) error
+	// Trash moves a post together with its comments to the trash. The comments are
+	// marked DeletedWithPost, so restoring the post brings back exactly these comments.
+	Trash(post *models.Post) error
+}
+
+type CommentRepository interface {
+	// Get returns a comment of the post whatever its status.
+	Get(postID, commentID uint) (models.PostComment, error)
+	// Visible returns a comment of the post visible to the viewer, with its mentions.
+	Visible(viewerID, postID, commentID uint) (models.PostComment, error)
+	// Replies returns the replies visible to the viewer of the given comments, oldest
+	// first, with their mentions.
+	Replies(viewerID uint, parentIDs []uint) ([]models.PostComment, error)
+	// Create saves a new comment. The reply count of the parent of a reply goes up
+	// in the same transaction.
+	Create(comment *models.PostComment) error
+	// Update saves the text and the status of a comment.
+	Update(comment *models.PostComment) error
+	// Delete removes a comment, or keeps it as a "[deleted]" placeholder when it still
+	// has replies so they stay visible, and reports which one it did. A placeholder
+	// parent that loses its last reply is removed as well.
+	Delete(comment *models.PostComment) (placeholder bool, err error)
+}
+
+// Relation is how a viewer relates to another user.
+type Relation struct {
+	Following bool
+	Blocking  bool
+	Muting    bool
+}
+
+type UserRepository interface {
+	// Create saves a new user, ErrDuplicate is returned when the user name or the
+	// email is taken.
+	Create(user *models.User) error
+	Get(userID uint) (models.User, error)
+	ByName(userName string) (models.User, error)
+	// ByCredential finds a user by email, or by user name when no email matches.
+	ByCredential(credential string) (models.User, error)
+	UpdatePassword(user *models.User, hash string) error
+	Profile(userID uint) (models.UserProfile, error)
+	// SaveProfile creates the profile when it has no ID yet and updates it otherwise.
+	SaveProfile(profile *models.UserProfile) error
+	// Blocked reports whether ownerID blocked actorID.
+	Blocked(ownerID, actorID uint) (bool, error)
+	Relation(viewerID, userID uint) (Relation, error)
+	FollowCounts(userID uint) (followers, following int64, err error)
+}
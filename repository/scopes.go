@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"github.com/dayiamin/gin_blog_api/config"
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+)
+
+// hiddenAuthors is a subquery of the users whose content viewerID does not see: users
+// blocked by the viewer, users who blocked the viewer and users the viewer muted.
+func hiddenAuthors(db *gorm.DB, viewerID uint) *gorm.DB {
+	return db.Raw(
+		"SELECT blocked_id FROM blocks WHERE user_id = ? UNION SELECT user_id FROM blocks WHERE blocked_id = ? UNION SELECT muted_id FROM mutes WHERE user_id = ?",
+		viewerID, viewerID, viewerID,
+	)
+}
+
+// hideBannedContent is set from the configuration by Configure.
+var hideBannedContent = config.Default().Moderation.HideBannedContent
+
+// Configure sets whether the content of banned users is left out of listings.
+func Configure(cfg config.Moderation) {
+	hideBannedContent = cfg.HideBannedContent
+}
+
+// HideBannedContent reports whether the content of banned users is left out of listings.
+// It is off by default.
+func HideBannedContent() bool {
+	return hideBannedContent
+}
+
+// VisibleTo is a scope for posts and comments that keeps published content, and the
+// viewer's own pending content, and drops the content of hiddenAuthors and, when
+// HideBannedContent is on, of banned users.
+func VisibleTo(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		// subqueries run on the connection of the query, which may be a transaction
+		session := query.Session(&gorm.Session{NewDB: true})
+		if HideBannedContent() {
+			query = query.Where("user_id NOT IN (?)", session.Model(&models.User{}).Select("id").Where("banned = ?", true))
+		}
+		if viewerID == 0 {
+			return query.Where("status = ?", models.StatusPublished)
+		}
+		return query.
+			Where("(status = ? OR (status = ? AND user_id = ?))", models.StatusPublished, models.StatusPending, viewerID).
+			Where("user_id NOT IN (?)", hiddenAuthors(session, viewerID))
+	}
+}
+
+// TaggedWith is a subquery of the IDs of the posts filed under the tag.
+func TaggedWith(db *gorm.DB, name string) *gorm.DB {
+	return db.Table("post_tags").Select("post_tags.post_id").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("tags.name = ?", models.TagName(name))
+}
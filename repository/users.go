@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"gorm.io/gorm"
+)
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r gormUsers) Create(user *models.User) error {
+	return translate(r.db.Create(user).Error)
+}
+
+func (r gormUsers) Get(userID uint) (models.User, error) {
+	var user models.User
+	err := r.db.Where("id = ?", userID).First(&user).Error
+	return user, translate(err)
+}
+
+func (r gormUsers) ByName(userName string) (models.User, error) {
+	var user models.User
+	err := r.db.Where("user_name = ?", userName).First(&user).Error
+	return user, translate(err)
+}
+
+func (r gormUsers) ByCredential(credential string) (models.User, error) {
+	var user models.User
+	err := r.db.Where("email = ?", credential).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.db.Where("user_name = ?", credential).First(&user).Error
+	}
+	return user, translate(err)
+}
+
+func (r gormUsers) UpdatePassword(user *models.User, hash string) error {
+	return r.db.Model(user).Update("password", hash).Error
+}
+
+func (r gormUsers) Profile(userID uint) (models.UserProfile, error) {
+	var profile models.UserProfile
+	err := r.db.Where("user_id = ?", userID).First(&profile).Error
+	return profile, translate(err)
+}
+
+func (r gormUsers) SaveProfile(profile *models.UserProfile) error {
+	if profile.ID == 0 {
+		return r.db.Create(profile).Error
+	}
+	return r.db.Save(profile).Error
+}
+
+func (r gormUsers) Blocked(ownerID, actorID uint) (bool, error) {
+	return r.exists(&models.Block{}, "user_id = ? AND blocked_id = ?", ownerID, actorID)
+}
+
+func (r gormUsers) Relation(viewerID, userID uint) (Relation, error) {
+	var relation Relation
+	var err error
+	if relation.Following, err = r.exists(&models.Follow{}, "follower_id = ? AND followee_id = ?", viewerID, userID); err != nil {
+		return relation, err
+	}
+	if relation.Blocking, err = r.exists(&models.Block{}, "user_id = ? AND blocked_id = ?", viewerID, userID); err != nil {
+		return relation, err
+	}
+	relation.Muting, err = r.exists(&models.Mute{}, "user_id = ? AND muted_id = ?", viewerID, userID)
+	return relation, err
+}
+
+func (r gormUsers) FollowCounts(userID uint) (int64, int64, error) {
+	var followers, following int64
+	if err := r.db.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&followers).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := r.db.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&following).Error; err != nil {
+		return 0, 0, err
+	}
+	return followers, following, nil
+}
+
+func (r gormUsers) exists(model any, query string, args ...any) (bool, error) {
+	var count int64
+	err := r.db.Model(model).Where(query, args...).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(r *gin.RouterGroup) {
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(middleware.JwtAuth(), middleware.RequireRole(models.RoleAdmin))
+	{
+		adminGroup.PUT("/users/:user_name/role", handlers.SetUserRole)
+		adminGroup.POST("/users/:user_name/suspension", handlers.SuspendUser)
+		adminGroup.DELETE("/users/:user_name/suspension", handlers.UnsuspendUser)
+		adminGroup.GET("/suspensions", handlers.ShowSuspensions)
+		adminGroup.GET("/audit", handlers.ShowAuditLog)
+		adminGroup.GET("/audit/export", handlers.ExportAuditLog)
+		adminGroup.GET("/trash", handlers.ShowAllTrash)
+	}
+}
@@ -0,0 +1,164 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminRoles(t *testing.T) {
+	s := newServer(t)
+	admin := s.withRole("root", models.RoleAdmin)
+	moderator := s.withRole("mod", models.RoleModerator)
+	alice := s.register("alice")
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodPut, path: "/admin/users/alice/role",
+			body: gin.H{"role": models.RoleModerator}, want: http.StatusUnauthorized},
+		{name: "moderators are not admins", method: http.MethodPut, path: "/admin/users/alice/role", token: moderator.Token,
+			body: gin.H{"role": models.RoleModerator}, want: http.StatusForbidden},
+		{name: "unknown role", method: http.MethodPut, path: "/admin/users/alice/role", token: admin.Token,
+			body: gin.H{"role": "owner"}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "unknown user", method: http.MethodPut, path: "/admin/users/nobody/role", token: admin.Token,
+			body: gin.H{"role": models.RoleModerator}, want: http.StatusNotFound, code: "user_not_found"},
+		{name: "promote", method: http.MethodPut, path: "/admin/users/alice/role", token: admin.Token,
+			body: gin.H{"role": models.RoleModerator}, want: http.StatusOK},
+		{name: "promoted user moderates", method: http.MethodGet, path: "/moderation/reports", token: alice.Token,
+			want: http.StatusOK},
+	})
+}
+
+func TestSuspensions(t *testing.T) {
+	s := newServer(t)
+	admin := s.withRole("root", models.RoleAdmin)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	s.run([]apiCase{
+		{name: "without reason", method: http.MethodPost, path: "/admin/users/alice/suspension", token: admin.Token,
+			body: gin.H{"days": 3}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "yourself", method: http.MethodPost, path: "/admin/users/root/suspension", token: admin.Token,
+			body: gin.H{"reason": "test"}, want: http.StatusUnprocessableEntity, code: apierror.CodeSelfAction},
+		{name: "unknown user", method: http.MethodPost, path: "/admin/users/nobody/suspension", token: admin.Token,
+			body: gin.H{"reason": "spam"}, want: http.StatusNotFound, code: "user_not_found"},
+		{name: "suspend", method: http.MethodPost, path: "/admin/users/alice/suspension", token: admin.Token,
+			body: gin.H{"reason": "spam", "days": 3}, want: http.StatusOK},
+		{name: "ban", method: http.MethodPost, path: "/admin/users/bob/suspension", token: admin.Token,
+			body: gin.H{"reason": "abuse", "ban": true}, want: http.StatusOK},
+		{name: "suspended user is locked out", method: http.MethodGet, path: "/feed", token: alice.Token,
+			want: http.StatusForbidden},
+		{name: "banned user is locked out", method: http.MethodGet, path: "/feed", token: bob.Token,
+			want: http.StatusForbidden},
+		{name: "suspended user can not log in", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "alice", "password": password}, want: http.StatusForbidden},
+		{name: "all suspensions", method: http.MethodGet, path: "/admin/suspensions", token: admin.Token,
+			want: http.StatusOK, check: count("suspensions", 2)},
+		{name: "bans only", method: http.MethodGet, path: "/admin/suspensions?banned=true", token: admin.Token,
+			want: http.StatusOK, check: count("suspensions", 1)},
+		{name: "lift", method: http.MethodDelete, path: "/admin/users/alice/suspension", token: admin.Token,
+			want: http.StatusOK},
+		{name: "back in", method: http.MethodGet, path: "/feed", token: alice.Token,
+			want: http.StatusOK},
+		{name: "one left", method: http.MethodGet, path: "/admin/suspensions", token: admin.Token,
+			want: http.StatusOK, check: count("suspensions", 1)},
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	s := newServer(t)
+	admin := s.withRole("root", models.RoleAdmin)
+	s.register("alice")
+	s.request(http.MethodPut, "/admin/users/alice/role", admin.Token, gin.H{"role": models.RoleModerator})
+	s.request(http.MethodPost, "/admin/users/alice/suspension", admin.Token, gin.H{"reason": "spam"})
+
+	s.run([]apiCase{
+		{name: "without role", method: http.MethodGet, path: "/admin/audit", token: s.register("bob").Token,
+			want: http.StatusForbidden},
+		{name: "entries", method: http.MethodGet, path: fmt.Sprintf("/admin/audit?actor_id=%d", admin.ID), token: admin.Token,
+			want: http.StatusOK, check: count("entries", 2)},
+		{name: "by action", method: http.MethodGet, path: "/admin/audit?action=user.suspend", token: admin.Token,
+			want: http.StatusOK, check: count("entries", 1)},
+		{name: "since in the future", method: http.MethodGet, path: "/admin/audit?since=2999-01-01T00:00:00Z", token: admin.Token,
+			want: http.StatusOK, check: count("entries", 0)},
+		{name: "invalid since", method: http.MethodGet, path: "/admin/audit?since=yesterday", token: admin.Token,
+			want: http.StatusBadRequest},
+		{name: "export", method: http.MethodGet, path: fmt.Sprintf("/admin/audit/export?actor_id=%d", admin.ID), token: admin.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+				if len(lines) != 2 || !json.Valid([]byte(lines[0])) {
+					t.Errorf("export = %s", res.Body)
+				}
+			}},
+		{name: "empty export", method: http.MethodGet, path: "/admin/audit/export?since=2999-01-01T00:00:00Z", token: admin.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if res.Body.Len() != 0 || res.Header().Get("Content-Disposition") == "" {
+					t.Errorf("export = %q with headers %v", res.Body, res.Header())
+				}
+			}},
+	})
+
+	// a query that fails before anything is sent is answered with an error, not an
+	// empty file
+	if err := db.DB.Migrator().DropTable(&models.AuditLog{}); err != nil {
+		t.Fatal(err)
+	}
+	s.run([]apiCase{
+		{name: "failed export", method: http.MethodGet, path: "/admin/audit/export", token: admin.Token,
+			want: http.StatusInternalServerError, code: apierror.CodeInternal, check: func(t *testing.T, res response) {
+				if res.Header().Get("Content-Disposition") != "" {
+					t.Errorf("failed export is offered as a file: %v", res.Header())
+				}
+			}},
+	})
+}
+
+func TestFailedLoginAudit(t *testing.T) {
+	s := newServer(t)
+	admin := s.withRole("root", models.RoleAdmin)
+	for i := 0; i < 2; i++ {
+		s.request(http.MethodPost, "/user/login", "", gin.H{"credential": "hunter2-typed-here", "password": password})
+	}
+
+	s.run([]apiCase{
+		{name: "credential is not stored", method: http.MethodGet, path: "/admin/audit?action=user.login_failed", token: admin.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if strings.Contains(res.Body.String(), "hunter2") {
+					t.Errorf("the audit log holds the raw credential: %s", res.Body)
+				}
+				var entries []struct {
+					After map[string]any `json:"after"`
+				}
+				decode(t, res.JSON(t)["entries"], &entries)
+				if len(entries) != 2 || entries[0].After["credential_fingerprint"] == nil ||
+					entries[0].After["credential_fingerprint"] != entries[1].After["credential_fingerprint"] {
+					t.Errorf("entries = %v, want two attempts with the same fingerprint", entries)
+				}
+			}},
+	})
+}
+
+func TestAdminTrash(t *testing.T) {
+	s := newServer(t)
+	admin := s.withRole("root", models.RoleAdmin)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	for _, author := range []user{alice, bob} {
+		post := s.post(author, "post of "+author.UserName)
+		s.request(http.MethodDelete, fmt.Sprintf("/post/%d", post.ID), author.Token, nil)
+	}
+
+	s.run([]apiCase{
+		{name: "without role", method: http.MethodGet, path: "/admin/trash", token: alice.Token, want: http.StatusForbidden},
+		{name: "everyone's trash", method: http.MethodGet, path: "/admin/trash", token: admin.Token,
+			want: http.StatusOK, check: count("posts", 2)},
+		{name: "by user", method: http.MethodGet, path: "/admin/trash?user_name=bob", token: admin.Token,
+			want: http.StatusOK, check: count("posts", 1)},
+	})
+}
@@ -0,0 +1,73 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblems(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+
+	s.run([]apiCase{
+		{name: "unknown route", method: http.MethodGet, path: "/nowhere",
+			want: http.StatusNotFound, code: "route_not_found"},
+		{name: "invalid token", method: http.MethodGet, path: "/feed", token: "not-a-token",
+			want: http.StatusUnauthorized, code: apierror.CodeInvalidToken},
+		{name: "wrong type", method: http.MethodPost, path: "/post/register", token: alice.Token,
+			body: gin.H{"title": 42}, want: http.StatusBadRequest, code: apierror.CodeInvalidBody},
+		{name: "empty body", method: http.MethodPost, path: "/user/login",
+			want: http.StatusBadRequest, code: apierror.CodeInvalidBody},
+		{name: "every invalid field", method: http.MethodPost, path: "/user/register",
+			body: gin.H{"user_name": "al", "email": "alice"}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation,
+			check: func(t *testing.T, res response) {
+				body := res.JSON(t)
+				if body["status"] != float64(http.StatusUnprocessableEntity) || body["instance"] != "/api/v1/user/register" {
+					t.Errorf("problem = %v", body)
+				}
+				rules := map[string]string{}
+				for _, field := range body["errors"].([]any) {
+					field := field.(map[string]any)
+					rules[field["field"].(string)] = field["rule"].(string)
+				}
+				want := map[string]string{"user_name": "min", "email": "email", "password": "required"}
+				for name, rule := range want {
+					if rules[name] != rule {
+						t.Errorf("errors = %v, want %s to fail %s", rules, name, rule)
+					}
+				}
+			}},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/user/login", strings.NewReader(`{"credential":`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("malformed JSON: status %d, want 400", recorder.Code)
+	}
+	problemCode(apierror.CodeInvalidBody)(t, response{recorder})
+}
+
+func TestSuspendedProblem(t *testing.T) {
+	s := newServer(t)
+	admin := s.withRole("root", models.RoleAdmin)
+	alice := s.register("alice")
+	s.request(http.MethodPost, "/admin/users/alice/suspension", admin.Token, gin.H{"reason": "spam", "days": 2})
+
+	s.run([]apiCase{
+		{name: "reason and end of the suspension", method: http.MethodGet, path: "/feed", token: alice.Token,
+			want: http.StatusForbidden, code: apierror.CodeSuspended, check: func(t *testing.T, res response) {
+				body := res.JSON(t)
+				if body["reason"] != "spam" || body["suspended_until"] == nil {
+					t.Errorf("problem = %v", body)
+				}
+			}},
+	})
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func FeedRoutes(r *gin.RouterGroup) {
+	for _, format := range []string{"rss", "atom", "json"} {
+		r.GET("/feed."+format, handlers.ShowSiteFeed)
+		r.GET("/user/:user_name/feed."+format, handlers.ShowAuthorFeed)
+		r.GET("/tag/:tag/feed."+format, handlers.ShowTagFeed)
+	}
+	r.GET("/sitemap.xml", handlers.ShowSitemap)
+	r.GET("/sitemap/:page", handlers.ShowSitemapPage)
+
+	feedGroup := r.Group("/feed")
+	feedGroup.Use(middleware.JwtAuth())
+	{
+		feedGroup.GET("", handlers.ShowFeed)
+	}
+}
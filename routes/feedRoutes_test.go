@@ -0,0 +1,110 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/sitemap"
+)
+
+func TestSyndicationFeeds(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	s.post(alice, "tagged post", "go")
+	s.post(alice, "plain post")
+
+	contains := func(parts ...string) func(t *testing.T, res response) {
+		return func(t *testing.T, res response) {
+			for _, part := range parts {
+				if !strings.Contains(res.Body.String(), part) {
+					t.Errorf("body has no %q: %s", part, res.Body)
+				}
+			}
+		}
+	}
+
+	s.run([]apiCase{
+		{name: "rss", method: http.MethodGet, path: "/feed.rss",
+			want: http.StatusOK, check: contains("<rss", "tagged post", "plain post")},
+		{name: "atom", method: http.MethodGet, path: "/feed.atom",
+			want: http.StatusOK, check: contains("<feed", "tagged post")},
+		{name: "json", method: http.MethodGet, path: "/feed.json",
+			want: http.StatusOK, check: count("items", 2)},
+		{name: "author", method: http.MethodGet, path: "/user/alice/feed.json",
+			want: http.StatusOK, check: count("items", 2)},
+		{name: "unknown author", method: http.MethodGet, path: "/user/nobody/feed.rss",
+			want: http.StatusNotFound},
+		{name: "tag", method: http.MethodGet, path: "/tag/go/feed.json",
+			want: http.StatusOK, check: count("items", 1)},
+		{name: "unknown tag", method: http.MethodGet, path: "/tag/rust/feed.rss",
+			want: http.StatusNotFound},
+	})
+
+	first := s.request(http.MethodGet, "/feed.rss", "", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("the feed has no ETag")
+	}
+	if res := s.request(http.MethodGet, "/feed.rss", "", nil, "If-None-Match", etag); res.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: status %d, want 304", res.Code)
+	}
+	s.post(alice, "newer post")
+	if res := s.request(http.MethodGet, "/feed.rss", "", nil, "If-None-Match", etag); res.Code != http.StatusOK {
+		t.Errorf("If-None-Match after a new post: status %d, want 200", res.Code)
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	post := s.post(alice, "post")
+
+	s.run([]apiCase{
+		// a sitemap that fits on one page is served without an index
+		{name: "single page", method: http.MethodGet, path: "/sitemap.xml",
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if !strings.Contains(res.Body.String(), "<urlset") || !strings.Contains(res.Body.String(), "/user/profile/alice") {
+					t.Errorf("sitemap = %s", res.Body)
+				}
+			}},
+		{name: "first page", method: http.MethodGet, path: "/sitemap/1.xml",
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if !strings.Contains(res.Body.String(), "<urlset") || !strings.Contains(res.Body.String(), fmt.Sprintf("/post/%d", post.ID)) {
+					t.Errorf("page = %s", res.Body)
+				}
+			}},
+		{name: "page out of range", method: http.MethodGet, path: "/sitemap/9.xml", want: http.StatusNotFound},
+		{name: "bad page name", method: http.MethodGet, path: "/sitemap/one.xml", want: http.StatusNotFound},
+	})
+}
+
+func TestSitemapIndex(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.Site.URL = "https://blog.example/api/v1" })
+	s := newServer(t)
+	alice := s.register("alice")
+	posts := make([]models.Post, sitemap.MaxURLs)
+	for i := range posts {
+		posts[i] = models.Post{Title: "post", UserID: alice.ID, Status: models.StatusPublished}
+	}
+	if err := db.DB.CreateInBatches(posts, 1000).Error; err != nil {
+		t.Fatal(err)
+	}
+	sitemap.Invalidate()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sitemap.xml", nil)
+	req.Host = "evil.example"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK || !strings.Contains(body, "<sitemapindex") ||
+		!strings.Contains(body, "<loc>https://blog.example/api/v1/sitemap/2.xml</loc>") || strings.Contains(body, "evil.example") {
+		t.Errorf("index = %d %s", recorder.Code, body)
+	}
+}
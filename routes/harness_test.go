@@ -0,0 +1,344 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/feeds"
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/jobs"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/moderation"
+	"github.com/dayiamin/gin_blog_api/repository"
+	"github.com/dayiamin/gin_blog_api/routes"
+	"github.com/dayiamin/gin_blog_api/services"
+	"github.com/dayiamin/gin_blog_api/sitemap"
+	"github.com/dayiamin/gin_blog_api/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/logger"
+)
+
+// password is the password of every user created by the fixtures.
+const password = "secret1"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	configure(testConfig())
+	os.Exit(m.Run())
+}
+
+// testConfig is the configuration the tests run with. Only the link limit of the
+// moderation filters stays on so the fixtures can post freely and the tests can
+// still send content to the queue.
+func testConfig() config.Config {
+	cfg := config.Default()
+	cfg.JWT = config.JWT{Secret: "test-secret", TTL: config.Duration{Duration: time.Hour}}
+	cfg.Moderation.DuplicateWindow.Duration = 0
+	cfg.Moderation.NewAccountAge.Duration = 0
+	cfg.Moderation.MaxLinks = 1
+	return cfg
+}
+
+// configure hands cfg to the packages like main does.
+func configure(cfg config.Config) {
+	utils.ConfigureJWT(cfg.JWT)
+	services.ConfigureComments(cfg.Comments)
+	repository.Configure(cfg.Moderation)
+	if err := moderation.Configure(cfg.Moderation); err != nil {
+		panic(err)
+	}
+	jobs.ConfigureTrash(cfg.Trash)
+	analytics.Configure(cfg.Analytics)
+	feeds.Configure(cfg.Site)
+	handlers.Configure(cfg)
+}
+
+// withConfig runs the rest of the test with change applied to the test configuration.
+func withConfig(t *testing.T, change func(cfg *config.Config)) {
+	t.Helper()
+	cfg := testConfig()
+	change(&cfg)
+	configure(cfg)
+	t.Cleanup(func() { configure(testConfig()) })
+}
+
+// server is the API mounted on a fresh in-memory database.
+type server struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+// newServer connects db.DB to an in-memory SQLite database of its own, applies the
+// migrations and mounts every route group under /api/v1 like main does. The tests
+// that use it must not run in parallel, the handlers share db.DB.
+func newServer(t *testing.T) *server {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()))
+	err := db.Connect(config.Database{
+		Driver: config.DriverSQLite,
+		DSN:    dsn,
+		// one connection keeps the shared in-memory database from locking itself, and
+		// keeping it open keeps the database alive
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+		AutoMigrate:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB.Logger = logger.Default.LogMode(logger.Silent)
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := sitemap.Watch(db.DB); err != nil {
+		t.Fatal(err)
+	}
+	sitemap.Invalidate()
+
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Logger(), middleware.Metrics(), middleware.Errors(), middleware.Recovery())
+	router.NoRoute(middleware.NoRoute)
+	routes.MetricsRoutes(router)
+	v1 := router.Group("/api/v1")
+	store := repository.NewStore(db.DB)
+	routes.UserRoutes(v1, handlers.NewUserHandler(services.NewUserService(store)))
+	comments := handlers.NewCommentHandler(services.NewCommentService(store))
+	routes.PostRoutes(v1, handlers.NewPostHandler(services.NewPostService(store)), comments)
+	routes.FeedRoutes(v1)
+	routes.NotificationRoutes(v1)
+	routes.MeRoutes(v1)
+	routes.ModerationRoutes(v1, comments)
+	routes.AdminRoutes(v1)
+
+	return &server{t: t, router: router}
+}
+
+// response is a recorded response with helpers to read its body.
+type response struct {
+	*httptest.ResponseRecorder
+}
+
+// JSON decodes the body into a map.
+func (r response) JSON(t *testing.T) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(r.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %q: %v", r.Body.String(), err)
+	}
+	return body
+}
+
+// request sends a request to the API. path is relative to /api/v1, body is encoded as
+// JSON unless it is nil and token is sent as a bearer token unless it is empty.
+func (s *server) request(method, path, token string, body any, headers ...string) response {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (tests)")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return response{recorder}
+}
+
+// user is a registered user and a token to act as them.
+type user struct {
+	models.User
+	Token string
+}
+
+// register creates a user through the API and returns it with its token.
+func (s *server) register(name string) user {
+	s.t.Helper()
+	res := s.request(http.MethodPost, "/user/register", "", gin.H{"user_name": name, "email": name + "@example.com", "password": password})
+	if res.Code != http.StatusCreated {
+		s.t.Fatalf("register %s: %d %s", name, res.Code, res.Body)
+	}
+	var registered models.User
+	if err := db.DB.Where("user_name = ?", name).First(&registered).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return user{User: registered, Token: res.JSON(s.t)["token"].(string)}
+}
+
+// login returns the token of a successful login.
+func (s *server) login(credential, password string) string {
+	s.t.Helper()
+	res := s.request(http.MethodPost, "/user/login", "", gin.H{"credential": credential, "password": password})
+	if res.Code != http.StatusOK {
+		s.t.Fatalf("login %s: %d %s", credential, res.Code, res.Body)
+	}
+	return res.JSON(s.t)["token"].(string)
+}
+
+// withRole registers a user with the given role.
+func (s *server) withRole(name, role string) user {
+	s.t.Helper()
+	u := s.register(name)
+	if err := db.DB.Model(&models.User{}).Where("id = ?", u.ID).Update("role", role).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	u.Role = role
+	return u
+}
+
+// post creates a published post of author through the API.
+func (s *server) post(author user, title string, tags ...string) models.Post {
+	s.t.Helper()
+	res := s.request(http.MethodPost, "/post/register", author.Token, gin.H{"title": title, "caption": "about " + title, "tags": tags})
+	if res.Code != http.StatusCreated {
+		s.t.Fatalf("create post %q: %d %s", title, res.Code, res.Body)
+	}
+	var post models.Post
+	decode(s.t, res.JSON(s.t)["post"], &post)
+	return post
+}
+
+// comment creates a published comment of author on the post through the API.
+func (s *server) comment(author user, postID uint, text string) models.PostComment {
+	s.t.Helper()
+	return s.commentAt(author, fmt.Sprintf("/post/%d/comments/", postID), text)
+}
+
+// reply creates a published reply of author to a comment through the API.
+func (s *server) reply(author user, parent models.PostComment, text string) models.PostComment {
+	s.t.Helper()
+	return s.commentAt(author, fmt.Sprintf("/post/%d/comments/%d/replies", parent.PostID, parent.ID), text)
+}
+
+func (s *server) commentAt(author user, path, text string) models.PostComment {
+	s.t.Helper()
+	res := s.request(http.MethodPost, path, author.Token, gin.H{"text": text})
+	if res.Code != http.StatusCreated {
+		s.t.Fatalf("comment %q: %d %s", text, res.Code, res.Body)
+	}
+	var comment models.PostComment
+	decode(s.t, res.JSON(s.t)["comment"], &comment)
+	return comment
+}
+
+// decode converts a decoded JSON value into out.
+func decode(t *testing.T, value any, out any) {
+	t.Helper()
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(encoded, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// apiCase is one request of a table-driven test and the status it should get.
+type apiCase struct {
+	name   string
+	method string
+	path   string
+	token  string
+	body   any
+	want   int
+	// code is the problem code the response should have when set
+	code string
+	// check inspects the response further when set
+	check func(t *testing.T, res response)
+}
+
+// run sends the cases in order, so a case may depend on the ones before it.
+func (s *server) run(cases []apiCase) {
+	s.t.Helper()
+	for _, tc := range cases {
+		s.t.Run(tc.name, func(t *testing.T) {
+			sub := &server{t: t, router: s.router}
+			res := sub.request(tc.method, tc.path, tc.token, tc.body)
+			if res.Code != tc.want {
+				t.Fatalf("%s %s: status %d, want %d: %s", tc.method, tc.path, res.Code, tc.want, res.Body)
+			}
+			if tc.code != "" {
+				problemCode(tc.code)(t, res)
+			}
+			if tc.check != nil {
+				tc.check(t, res)
+			}
+		})
+	}
+}
+
+// problemCode checks that the response is a problem with the code.
+func problemCode(code string) func(t *testing.T, res response) {
+	return func(t *testing.T, res response) {
+		t.Helper()
+		if contentType := res.Header().Get("Content-Type"); !strings.HasPrefix(contentType, apierror.ContentType) {
+			t.Errorf("content type %q, want %s", contentType, apierror.ContentType)
+		}
+		if got := res.JSON(t)["code"]; got != code {
+			t.Errorf("code %v, want %s: %s", got, code, res.Body)
+		}
+	}
+}
+
+// invalidField checks that the validation problem lists field as failing rule.
+func invalidField(field, rule string) func(t *testing.T, res response) {
+	return func(t *testing.T, res response) {
+		t.Helper()
+		errors, _ := res.JSON(t)["errors"].([]any)
+		for _, fieldError := range errors {
+			if fieldError := fieldError.(map[string]any); fieldError["field"] == field && fieldError["rule"] == rule {
+				return
+			}
+		}
+		t.Errorf("errors %v do not list %s failing %s", errors, field, rule)
+	}
+}
+
+// hasKey checks that the JSON response has the key.
+func hasKey(key string) func(t *testing.T, res response) {
+	return func(t *testing.T, res response) {
+		t.Helper()
+		if _, ok := res.JSON(t)[key]; !ok {
+			t.Errorf("response %s has no %q", res.Body, key)
+		}
+	}
+}
+
+// count checks the length of the list under key in the JSON response.
+func count(key string, want int) func(t *testing.T, res response) {
+	return func(t *testing.T, res response) {
+		t.Helper()
+		list, _ := res.JSON(t)[key].([]any)
+		if len(list) != want {
+			t.Errorf("%q has %d entries, want %d: %s", key, len(list), want, res.Body)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package routes_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/logging"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/dayiamin/gin_blog_api/models"
+)
+
+// captureLogs sends the default logger to a buffer at debug level for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(config.Log{Level: config.LevelDebug, Format: config.FormatJSON}, &buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// accessLogs returns the access log records in buf.
+func accessLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", scanner.Text(), err)
+		}
+		if record["msg"] == "request" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func TestAccessLog(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	buf := captureLogs(t)
+
+	res := s.request(http.MethodGet, "/feed?access_token="+alice.Token, alice.Token, nil, middleware.RequestIDHeader, "req-42")
+	if res.Header().Get(middleware.RequestIDHeader) != "req-42" {
+		t.Errorf("X-Request-ID = %q, want the id the client sent", res.Header().Get(middleware.RequestIDHeader))
+	}
+	s.request(http.MethodPost, "/user/login", "", map[string]string{"credential": "alice", "password": "wrong-password"})
+
+	records := accessLogs(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d access log records, want 2:\n%s", len(records), buf)
+	}
+	feed, login := records[0], records[1]
+	if feed["request_id"] != "req-42" || feed["route"] != "/api/v1/feed" || feed["status"] != float64(http.StatusOK) ||
+		feed["user_id"] != float64(alice.ID) || feed["level"] != "INFO" || feed["latency"] == nil {
+		t.Errorf("feed record = %v", feed)
+	}
+	headers, _ := feed["headers"].(map[string]any)
+	if headers["Authorization"] != logging.Redacted {
+		t.Errorf("headers = %v, want Authorization redacted", headers)
+	}
+	if login["status"] != float64(http.StatusUnauthorized) || login["level"] != "WARN" || login["user_id"] != nil {
+		t.Errorf("login record = %v", login)
+	}
+	if login["request_id"] == "" || login["request_id"] == feed["request_id"] {
+		t.Errorf("login request_id = %v, want a generated id", login["request_id"])
+	}
+
+	for _, secret := range []string{alice.Token, "wrong-password"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("the logs contain %q:\n%s", secret, buf)
+		}
+	}
+}
+
+func TestInternalErrorLog(t *testing.T) {
+	s := newServer(t)
+	if err := db.DB.Migrator().DropTable(&models.Post{}); err != nil {
+		t.Fatal(err)
+	}
+	buf := captureLogs(t)
+
+	res := s.request(http.MethodGet, "/post/", "", nil)
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", res.Code)
+	}
+	problemCode(apierror.CodeInternal)(t, res)
+	if strings.Contains(res.Body.String(), "no such table") {
+		t.Errorf("the problem shows its cause: %s", res.Body)
+	}
+
+	var failed map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil && record["msg"] == "request failed" {
+			failed = record
+		}
+	}
+	cause, _ := failed["error"].(string)
+	if failed["detail"] != "could not fetch posts" || !strings.Contains(cause, "no such table") {
+		t.Errorf("request failed record = %v, want the detail and the cause", failed)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func MeRoutes(r *gin.RouterGroup) {
+	meGroup := r.Group("/me")
+	meGroup.Use(middleware.JwtAuth())
+	{
+		meGroup.GET("/analytics", handlers.ShowAnalytics)
+		meGroup.GET("/bookmarks", handlers.ShowBookmarks)
+		meGroup.GET("/bookmarks/collections", handlers.ShowBookmarkCollections)
+		meGroup.POST("/bookmarks/collections", handlers.CreateBookmarkCollection)
+		meGroup.DELETE("/bookmarks/collections/:collection_id", handlers.DeleteBookmarkCollection)
+	}
+}
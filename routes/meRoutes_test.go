@@ -0,0 +1,86 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/analytics"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAnalytics(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "post")
+	s.comment(bob, post.ID, "comment")
+	s.request(http.MethodPut, fmt.Sprintf("/post/%d/reactions", post.ID), bob.Token, gin.H{"type": "like"})
+	today := time.Now().UTC().Format(analytics.DayFormat)
+	if err := db.DB.Create(&models.PostDailyStat{PostID: post.ID, Day: today, Views: 3}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodGet, path: "/me/analytics", want: http.StatusUnauthorized},
+		{name: "own posts", method: http.MethodGet, path: "/me/analytics?days=7", token: alice.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				posts := res.JSON(t)["posts"].([]any)
+				if len(posts) != 1 {
+					t.Fatalf("posts = %v", posts)
+				}
+				summary := posts[0].(map[string]any)
+				if summary["views"] != float64(3) || summary["reactions"] != float64(1) || summary["comments"] != float64(1) {
+					t.Errorf("summary = %v", summary)
+				}
+			}},
+		{name: "no posts", method: http.MethodGet, path: "/me/analytics", token: bob.Token,
+			want: http.StatusOK, check: count("posts", 0)},
+	})
+}
+
+func TestBookmarkLists(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	first := s.post(alice, "first")
+	second := s.post(alice, "second")
+	s.request(http.MethodPut, fmt.Sprintf("/post/%d/bookmark", first.ID), bob.Token, nil)
+	s.request(http.MethodPut, fmt.Sprintf("/post/%d/bookmark", second.ID), bob.Token, gin.H{"collection": "later"})
+	var later models.BookmarkCollection
+	if err := db.DB.Where("user_id = ? AND name = ?", bob.ID, "later").First(&later).Error; err != nil {
+		t.Fatal(err)
+	}
+	s.request(http.MethodDelete, fmt.Sprintf("/post/%d", second.ID), alice.Token, nil)
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodGet, path: "/me/bookmarks", want: http.StatusUnauthorized},
+		{name: "all bookmarks", method: http.MethodGet, path: "/me/bookmarks", token: bob.Token,
+			want: http.StatusOK, check: count("bookmarks", 2)},
+		{name: "deleted posts are kept as unavailable", method: http.MethodGet, path: fmt.Sprintf("/me/bookmarks?collection_id=%d", later.ID), token: bob.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				saved := res.JSON(t)["bookmarks"].([]any)
+				if len(saved) != 1 || saved[0].(map[string]any)["available"] != false {
+					t.Errorf("bookmarks = %v", saved)
+				}
+			}},
+		{name: "collections", method: http.MethodGet, path: "/me/bookmarks/collections", token: bob.Token,
+			want: http.StatusOK, check: count("collections", 1)},
+		{name: "create collection without name", method: http.MethodPost, path: "/me/bookmarks/collections", token: bob.Token,
+			body: gin.H{}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "create collection", method: http.MethodPost, path: "/me/bookmarks/collections", token: bob.Token,
+			body: gin.H{"name": "recipes"}, want: http.StatusCreated},
+		{name: "create collection twice", method: http.MethodPost, path: "/me/bookmarks/collections", token: bob.Token,
+			body: gin.H{"name": "recipes"}, want: http.StatusConflict},
+		{name: "delete collection of another user", method: http.MethodDelete, path: fmt.Sprintf("/me/bookmarks/collections/%d", later.ID), token: alice.Token,
+			want: http.StatusNotFound},
+		{name: "delete collection", method: http.MethodDelete, path: fmt.Sprintf("/me/bookmarks/collections/%d", later.ID), token: bob.Token,
+			want: http.StatusOK},
+		{name: "collections left", method: http.MethodGet, path: "/me/bookmarks/collections", token: bob.Token,
+			want: http.StatusOK, check: count("collections", 1)},
+	})
+}
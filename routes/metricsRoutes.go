@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/dayiamin/gin_blog_api/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRoutes serves the Prometheus metrics at /metrics, next to the API rather than
+// under its version prefix.
+func MetricsRoutes(r gin.IRouter) {
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
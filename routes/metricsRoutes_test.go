@@ -0,0 +1,92 @@
+package routes_test
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/jobs"
+)
+
+// scrape returns the samples of /metrics by series, like
+// auth_logins_total{result="success"}.
+func (s *server) scrape() map[string]float64 {
+	s.t.Helper()
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		s.t.Fatalf("GET /metrics: %d %s", recorder.Code, recorder.Body)
+	}
+	samples := map[string]float64{}
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cut := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[cut+1:], 64)
+		if err != nil {
+			s.t.Fatalf("metrics line %q: %v", line, err)
+		}
+		samples[line[:cut]] = value
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "Hello")
+	s.comment(bob, post.ID, "hi")
+	before := s.scrape()
+
+	s.login("alice", password)
+	s.request(http.MethodPost, "/user/login", "", map[string]string{"credential": "alice", "password": "wrong-password"})
+	s.request(http.MethodGet, fmt.Sprintf("/post/%d", post.ID), "", nil)
+	s.request(http.MethodGet, "/post/999999", "", nil)
+	s.request(http.MethodGet, "/nowhere/42", "", nil)
+	if err := jobs.RefreshMetrics(); err != nil {
+		t.Fatal(err)
+	}
+	after := s.scrape()
+
+	increased := func(series string, want float64) {
+		t.Helper()
+		if got := after[series] - before[series]; got != want {
+			t.Errorf("%s went up by %v, want %v", series, got, want)
+		}
+	}
+	increased(`auth_logins_total{result="success"}`, 1)
+	increased(`auth_logins_total{result="failure"}`, 1)
+	increased(`http_requests_total{method="GET",route="/api/v1/post/:post_id",status="200"}`, 1)
+	increased(`http_requests_total{method="GET",route="/api/v1/post/:post_id",status="404"}`, 1)
+	increased(`http_requests_total{method="GET",route="unmatched",status="404"}`, 1)
+	increased(`http_request_duration_seconds_count{method="GET",route="/api/v1/post/:post_id"}`, 2)
+
+	for series := range after {
+		if strings.Contains(series, "/post/999999") || strings.Contains(series, "/nowhere") {
+			t.Errorf("series %s is labeled with a raw path", series)
+		}
+	}
+	if after[`db_query_duration_seconds_count{operation="query",table="posts"}`] == 0 {
+		t.Error("no query on posts was timed")
+	}
+
+	gauges := map[string]float64{
+		`blog_posts{status="published"}`:    1,
+		`blog_posts{status="pending"}`:      0,
+		`blog_comments{status="published"}`: 1,
+		`blog_users`:                        2,
+	}
+	for series, want := range gauges {
+		if got, ok := after[series]; !ok || got != want {
+			t.Errorf("%s = %v, want %v", series, got, want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func ModerationRoutes(r *gin.RouterGroup, comments *handlers.CommentHandler) {
+	moderationGroup := r.Group("/moderation")
+	moderationGroup.Use(middleware.JwtAuth(), middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+	{
+		moderationGroup.GET("/reports", handlers.ShowReports)
+		moderationGroup.POST("/reports/:report_id/action", handlers.TakeModerationAction)
+		moderationGroup.GET("/actions", handlers.ShowModerationActions)
+		moderationGroup.GET("/pending", handlers.ShowPendingContent)
+		moderationGroup.POST("/posts/:post_id/approve", handlers.ApproveContent)
+		moderationGroup.POST("/posts/:post_id/reject", handlers.RejectContent)
+		moderationGroup.POST("/comments/:comment_id/approve", comments.ApproveComment)
+		moderationGroup.POST("/comments/:comment_id/reject", handlers.RejectContent)
+	}
+}
@@ -0,0 +1,139 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestModerationReports(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	moderator := s.withRole("mod", models.RoleModerator)
+	spam := s.post(alice, "spam")
+	fine := s.post(alice, "fine")
+	s.request(http.MethodPost, fmt.Sprintf("/post/%d/report", spam.ID), bob.Token, gin.H{"reason": "spam"})
+	s.request(http.MethodPost, fmt.Sprintf("/post/%d/report", fine.ID), bob.Token, gin.H{"reason": "other"})
+	var reports []models.Report
+	if err := db.DB.Order("id").Find(&reports).Error; err != nil || len(reports) != 2 {
+		t.Fatalf("reports = %v, err %v", reports, err)
+	}
+	hide := fmt.Sprintf("/moderation/reports/%d/action", reports[0].ID)
+	dismiss := fmt.Sprintf("/moderation/reports/%d/action", reports[1].ID)
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodGet, path: "/moderation/reports", want: http.StatusUnauthorized},
+		{name: "without role", method: http.MethodGet, path: "/moderation/reports", token: bob.Token, want: http.StatusForbidden},
+		{name: "open reports", method: http.MethodGet, path: "/moderation/reports", token: moderator.Token,
+			want: http.StatusOK, check: count("reports", 2)},
+		{name: "filter by reason", method: http.MethodGet, path: "/moderation/reports?reason=spam", token: moderator.Token,
+			want: http.StatusOK, check: count("reports", 1)},
+		{name: "unknown action", method: http.MethodPost, path: hide, token: moderator.Token,
+			body: gin.H{"action": "shout"}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "missing report", method: http.MethodPost, path: "/moderation/reports/999/action", token: moderator.Token,
+			body: gin.H{"action": "hide"}, want: http.StatusNotFound},
+		{name: "hide", method: http.MethodPost, path: hide, token: moderator.Token,
+			body: gin.H{"action": "hide", "note": "ads"}, want: http.StatusOK},
+		{name: "already resolved", method: http.MethodPost, path: hide, token: moderator.Token,
+			body: gin.H{"action": "delete"}, want: http.StatusConflict},
+		{name: "hidden post", method: http.MethodGet, path: fmt.Sprintf("/post/%d", spam.ID), token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "dismiss", method: http.MethodPost, path: dismiss, token: moderator.Token,
+			body: gin.H{"action": "dismiss"}, want: http.StatusOK},
+		{name: "dismissed post stays", method: http.MethodGet, path: fmt.Sprintf("/post/%d", fine.ID), token: bob.Token,
+			want: http.StatusOK},
+		{name: "no open reports left", method: http.MethodGet, path: "/moderation/reports", token: moderator.Token,
+			want: http.StatusOK, check: count("reports", 0)},
+		{name: "all reports", method: http.MethodGet, path: "/moderation/reports?status=all", token: moderator.Token,
+			want: http.StatusOK, check: count("reports", 2)},
+		{name: "actions", method: http.MethodGet, path: fmt.Sprintf("/moderation/actions?target_type=post&target_id=%d", spam.ID), token: moderator.Token,
+			want: http.StatusOK, check: count("actions", 1)},
+	})
+}
+
+func TestModerationQueue(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	moderator := s.withRole("mod", models.RoleModerator)
+	published := s.post(alice, "published")
+	links := "https://a.example https://b.example"
+
+	var held, rejected models.Post
+	decode(t, s.request(http.MethodPost, "/post/register", alice.Token, gin.H{"title": "held", "caption": links}).JSON(t)["post"], &held)
+	decode(t, s.request(http.MethodPost, "/post/register", alice.Token, gin.H{"title": "rejected", "caption": links}).JSON(t)["post"], &rejected)
+	var comment models.PostComment
+	decode(t, s.request(http.MethodPost, fmt.Sprintf("/post/%d/comments/", published.ID), bob.Token, gin.H{"text": links}).JSON(t)["comment"], &comment)
+	if held.Status != models.StatusPending || comment.Status != models.StatusPending {
+		t.Fatalf("post %s, comment %s, want both pending", held.Status, comment.Status)
+	}
+
+	s.run([]apiCase{
+		{name: "without role", method: http.MethodGet, path: "/moderation/pending", token: alice.Token, want: http.StatusForbidden},
+		{name: "queue", method: http.MethodGet, path: "/moderation/pending", token: moderator.Token,
+			want: http.StatusOK, check: count("pending", 3)},
+		{name: "approve post", method: http.MethodPost, path: fmt.Sprintf("/moderation/posts/%d/approve", held.ID), token: moderator.Token,
+			want: http.StatusOK},
+		{name: "approve it again", method: http.MethodPost, path: fmt.Sprintf("/moderation/posts/%d/approve", held.ID), token: moderator.Token,
+			want: http.StatusNotFound},
+		{name: "approved post is public", method: http.MethodGet, path: fmt.Sprintf("/post/%d", held.ID),
+			want: http.StatusOK},
+		{name: "reject post", method: http.MethodPost, path: fmt.Sprintf("/moderation/posts/%d/reject", rejected.ID), token: moderator.Token,
+			body: gin.H{"note": "link farm"}, want: http.StatusOK},
+		{name: "rejected post stays hidden", method: http.MethodGet, path: fmt.Sprintf("/post/%d", rejected.ID),
+			want: http.StatusNotFound},
+		{name: "approve comment", method: http.MethodPost, path: fmt.Sprintf("/moderation/comments/%d/approve", comment.ID), token: moderator.Token,
+			want: http.StatusOK},
+		{name: "approve missing comment", method: http.MethodPost, path: "/moderation/comments/999/approve", token: moderator.Token,
+			want: http.StatusNotFound},
+		{name: "queue is empty", method: http.MethodGet, path: "/moderation/pending", token: moderator.Token,
+			want: http.StatusOK, check: count("pending", 0)},
+	})
+}
+
+func TestApprovedEdits(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	moderator := s.withRole("mod", models.RoleModerator)
+	hidden := s.post(alice, "hidden")
+	post := s.post(alice, "post")
+	comment := s.comment(bob, post.ID, "first")
+	links := "https://a.example https://b.example"
+	if err := db.DB.Model(&models.Post{}).Where("id = ?", hidden.ID).Update("status", models.StatusHidden).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	postPath := fmt.Sprintf("/post/%d", hidden.ID)
+	commentPath := fmt.Sprintf("/post/%d/comments/%d", post.ID, comment.ID)
+	s.run([]apiCase{
+		{name: "notified of the comment once", method: http.MethodGet, path: "/notifications", token: alice.Token,
+			want: http.StatusOK, check: count("notifications", 1)},
+		{name: "edit of a hidden post is held", method: http.MethodPut, path: postPath, token: alice.Token,
+			body: gin.H{"caption": links}, want: http.StatusOK},
+		{name: "edit of a comment is held", method: http.MethodPut, path: commentPath, token: bob.Token,
+			body: gin.H{"text": links}, want: http.StatusOK},
+		{name: "approve the post edit", method: http.MethodPost, path: fmt.Sprintf("/moderation/posts/%d/approve", hidden.ID), token: moderator.Token,
+			want: http.StatusOK},
+		{name: "post is still hidden", method: http.MethodGet, path: postPath,
+			want: http.StatusNotFound},
+		{name: "approve the comment edit", method: http.MethodPost, path: fmt.Sprintf("/moderation/comments/%d/approve", comment.ID), token: moderator.Token,
+			want: http.StatusOK},
+		{name: "comment is not announced again", method: http.MethodGet, path: "/notifications", token: alice.Token,
+			want: http.StatusOK, check: count("notifications", 1)},
+	})
+
+	var stored models.PostComment
+	if err := db.DB.First(&stored, comment.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.StatusPublished || stored.Text != links || stored.HeldFrom != "" {
+		t.Errorf("comment = %s %q held from %q, want the published edit", stored.Status, stored.Text, stored.HeldFrom)
+	}
+}
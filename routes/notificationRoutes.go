@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/dayiamin/gin_blog_api/handlers"
+	"github.com/dayiamin/gin_blog_api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoutes(r *gin.RouterGroup) {
+	notificationGroup := r.Group("/notifications")
+	notificationGroup.GET("/stream", middleware.JwtAuthQuery(), handlers.StreamNotifications)
+	notificationGroup.Use(middleware.JwtAuth())
+	{
+		notificationGroup.GET("", handlers.ShowNotifications)
+		notificationGroup.POST("/read-all", handlers.MarkAllNotificationsRead)
+		notificationGroup.POST("/:notification_id/read", handlers.MarkNotificationRead)
+		notificationGroup.GET("/preferences", handlers.ShowNotificationPreferences)
+		notificationGroup.PUT("/preferences", handlers.UpdateNotificationPreferences)
+	}
+}
@@ -0,0 +1,72 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotifications(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "post")
+	s.comment(bob, post.ID, "nice post @alice")
+	s.request(http.MethodPost, "/user/alice/follow", bob.Token, nil)
+	var first models.Notification
+	if err := db.DB.Where("user_id = ?", alice.ID).Order("id").First(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodGet, path: "/notifications", want: http.StatusUnauthorized},
+		{name: "list", method: http.MethodGet, path: "/notifications", token: alice.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				body := res.JSON(t)
+				list := body["notifications"].([]any)
+				if len(list) < 2 || body["unread_count"] != float64(len(list)) {
+					t.Errorf("notifications = %v", body)
+				}
+			}},
+		{name: "nothing for the actor", method: http.MethodGet, path: "/notifications", token: bob.Token,
+			want: http.StatusOK, check: count("notifications", 0)},
+		{name: "read notification of another user", method: http.MethodPost, path: fmt.Sprintf("/notifications/%d/read", first.ID), token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "read", method: http.MethodPost, path: fmt.Sprintf("/notifications/%d/read", first.ID), token: alice.Token,
+			want: http.StatusOK},
+		{name: "read all", method: http.MethodPost, path: "/notifications/read-all", token: alice.Token,
+			want: http.StatusOK},
+		{name: "no unread left", method: http.MethodGet, path: "/notifications?unread=true", token: alice.Token,
+			want: http.StatusOK, check: count("notifications", 0)},
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodGet, path: "/notifications/preferences", want: http.StatusUnauthorized},
+		{name: "defaults", method: http.MethodGet, path: "/notifications/preferences", token: alice.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				preferences := res.JSON(t)["preferences"].(map[string]any)
+				if preferences[models.NotificationFollow] != true {
+					t.Errorf("preferences = %v, every type is on by default", preferences)
+				}
+			}},
+		{name: "unknown type", method: http.MethodPut, path: "/notifications/preferences", token: alice.Token,
+			body: gin.H{"birthday": false}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "turn off follows", method: http.MethodPut, path: "/notifications/preferences", token: alice.Token,
+			body: gin.H{models.NotificationFollow: false}, want: http.StatusOK},
+		{name: "follow", method: http.MethodPost, path: "/user/alice/follow", token: bob.Token,
+			want: http.StatusOK},
+		{name: "no follow notification", method: http.MethodGet, path: "/notifications", token: alice.Token,
+			want: http.StatusOK, check: count("notifications", 0)},
+	})
+}
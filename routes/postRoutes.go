@@ -6,19 +6,38 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func PostRoutes(r *gin.RouterGroup) {
+func PostRoutes(r *gin.RouterGroup, posts *handlers.PostHandler, comments *handlers.CommentHandler) {
 	postGroup := r.Group("/post")
 	{
-		postGroup.GET("/", handlers.ShowPosts)
+		postGroup.GET("/", middleware.OptionalJwtAuth(), posts.ShowPosts)
+		postGroup.GET("/trending", middleware.OptionalJwtAuth(), handlers.ShowTrending)
+		postGroup.GET("/:post_id", middleware.OptionalJwtAuth(), posts.ShowPost)
+		postGroup.GET("/:post_id/comments/:comment_id/thread", middleware.OptionalJwtAuth(), comments.ShowCommentThread)
+		postGroup.GET("/:post_id/reactions", middleware.OptionalJwtAuth(), handlers.ShowReactions)
+		postGroup.GET("/:post_id/comments/:comment_id/reactions", middleware.OptionalJwtAuth(), handlers.ShowReactions)
+		postGroup.GET("/:post_id/comments/stream", middleware.JwtAuthQuery(), handlers.StreamComments)
 		postGroup.Use(middleware.JwtAuth())
-		postGroup.POST("/register", handlers.RegisterPost)
-		postGroup.DELETE("/:post_id", handlers.DeletePost)
+		postGroup.POST("/register", posts.RegisterPost)
+		postGroup.GET("/trash", handlers.ShowTrash)
+		postGroup.POST("/:post_id/restore", handlers.RestorePost)
+		postGroup.PUT("/:post_id", posts.UpdatePost)
+		postGroup.DELETE("/:post_id", posts.DeletePost)
+		postGroup.PUT("/:post_id/reactions", handlers.SetReaction)
+		postGroup.DELETE("/:post_id/reactions", handlers.RemoveReaction)
+		postGroup.POST("/:post_id/report", handlers.ReportContent)
+		postGroup.PUT("/:post_id/bookmark", handlers.SetBookmark)
+		postGroup.DELETE("/:post_id/bookmark", handlers.RemoveBookmark)
 	}
 	commentGroup := postGroup.Group("/:post_id/comments")
 
 	{
-		commentGroup.POST("/", handlers.RegisterComment)
-		commentGroup.DELETE("/:comment_id", handlers.DeleteComment)
+		commentGroup.POST("/", comments.RegisterComment)
+		commentGroup.PUT("/:comment_id", comments.UpdateComment)
+		commentGroup.DELETE("/:comment_id", comments.DeleteComment)
+		commentGroup.POST("/:comment_id/replies", comments.RegisterReply)
+		commentGroup.PUT("/:comment_id/reactions", handlers.SetReaction)
+		commentGroup.DELETE("/:comment_id/reactions", handlers.RemoveReaction)
+		commentGroup.POST("/:comment_id/report", handlers.ReportContent)
 	}
 
 }
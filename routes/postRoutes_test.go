@@ -0,0 +1,669 @@
+package routes_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/dayiamin/gin_blog_api/analytics"
+	"github.com/dayiamin/gin_blog_api/config"
+	db "github.com/dayiamin/gin_blog_api/database"
+	"github.com/dayiamin/gin_blog_api/jobs"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestPosts(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "hello", "go")
+	path := fmt.Sprintf("/post/%d", post.ID)
+
+	s.run([]apiCase{
+		{name: "list", method: http.MethodGet, path: "/post/",
+			want: http.StatusOK, check: count("posts", 1)},
+		{name: "list by tag", method: http.MethodGet, path: "/post/?tag=GO",
+			want: http.StatusOK, check: count("posts", 1)},
+		{name: "list by unknown tag", method: http.MethodGet, path: "/post/?tag=rust",
+			want: http.StatusOK, check: count("posts", 0)},
+		{name: "get", method: http.MethodGet, path: path,
+			want: http.StatusOK, check: hasKey("post")},
+		{name: "get missing", method: http.MethodGet, path: "/post/999",
+			want: http.StatusNotFound},
+		{name: "get invalid id", method: http.MethodGet, path: "/post/abc",
+			want: http.StatusNotFound},
+		{name: "create without token", method: http.MethodPost, path: "/post/register",
+			body: gin.H{"title": "t"}, want: http.StatusUnauthorized},
+		{name: "create with too many tags", method: http.MethodPost, path: "/post/register", token: alice.Token,
+			body: gin.H{"title": "t", "tags": []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "create with a long title", method: http.MethodPost, path: "/post/register", token: alice.Token,
+			body: gin.H{"title": strings.Repeat("t", 251)},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation, check: invalidField("title", "max")},
+		{name: "create with a long caption", method: http.MethodPost, path: "/post/register", token: alice.Token,
+			body: gin.H{"title": "t", "caption": strings.Repeat("c", 1001)},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation, check: invalidField("caption", "max")},
+		{name: "create", method: http.MethodPost, path: "/post/register", token: bob.Token,
+			body: gin.H{"title": "bob's post", "caption": "hi", "tags": []string{"#Web"}},
+			want: http.StatusCreated, check: func(t *testing.T, res response) {
+				created := res.JSON(t)["post"].(map[string]any)
+				tags := created["tags"].([]any)
+				if created["status"] != models.StatusPublished || len(tags) != 1 || tags[0].(map[string]any)["name"] != "web" {
+					t.Errorf("post = %v", created)
+				}
+			}},
+		{name: "update without token", method: http.MethodPut, path: path,
+			body: gin.H{"title": "t"}, want: http.StatusUnauthorized},
+		{name: "update post of another user", method: http.MethodPut, path: path, token: bob.Token,
+			body: gin.H{"title": "mine now"}, want: http.StatusForbidden},
+		{name: "update missing post", method: http.MethodPut, path: "/post/999", token: alice.Token,
+			body: gin.H{"title": "t"}, want: http.StatusNotFound},
+		{name: "update", method: http.MethodPut, path: path, token: alice.Token,
+			body: gin.H{"title": "hello again"}, want: http.StatusOK,
+			check: func(t *testing.T, res response) {
+				updated := res.JSON(t)["post"].(map[string]any)
+				if updated["title"] != "hello again" || updated["caption"] != "about hello" || len(updated["tags"].([]any)) != 1 {
+					t.Errorf("post = %v, empty fields and tags should be kept", updated)
+				}
+			}},
+		{name: "update with a long title", method: http.MethodPut, path: path, token: alice.Token,
+			body: gin.H{"title": strings.Repeat("t", 251)},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation, check: invalidField("title", "max")},
+		{name: "update with too many tags", method: http.MethodPut, path: path, token: alice.Token,
+			body: gin.H{"tags": []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "update replaces tags", method: http.MethodPut, path: path, token: alice.Token,
+			body: gin.H{"tags": []string{}}, want: http.StatusOK,
+			check: func(t *testing.T, res response) {
+				if tags := res.JSON(t)["post"].(map[string]any)["tags"].([]any); len(tags) != 0 {
+					t.Errorf("tags = %v, want none", tags)
+				}
+			}},
+		{name: "delete without token", method: http.MethodDelete, path: path,
+			want: http.StatusUnauthorized},
+		{name: "delete post of another user", method: http.MethodDelete, path: path, token: bob.Token,
+			want: http.StatusForbidden},
+		{name: "delete missing post", method: http.MethodDelete, path: "/post/999", token: alice.Token,
+			want: http.StatusNotFound, code: "post_not_found"},
+		{name: "delete", method: http.MethodDelete, path: path, token: alice.Token,
+			want: http.StatusOK},
+		{name: "deleted post is gone", method: http.MethodGet, path: path,
+			want: http.StatusNotFound},
+	})
+}
+
+func TestPendingPosts(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	// more links than MODERATION_MAX_LINKS sends the post to the pending queue
+	res := s.request(http.MethodPost, "/post/register", alice.Token, gin.H{"title": "links", "caption": "https://a.example https://b.example"})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", res.Code, res.Body)
+	}
+	var post models.Post
+	decode(t, res.JSON(t)["post"], &post)
+	if post.Status != models.StatusPending {
+		t.Fatalf("status = %s, want pending", post.Status)
+	}
+	path := fmt.Sprintf("/post/%d", post.ID)
+
+	s.run([]apiCase{
+		{name: "author sees it", method: http.MethodGet, path: path, token: alice.Token, want: http.StatusOK},
+		{name: "hidden from others", method: http.MethodGet, path: path, token: bob.Token, want: http.StatusNotFound},
+		{name: "hidden from anonymous visitors", method: http.MethodGet, path: "/post/", want: http.StatusOK,
+			check: count("posts", 0)},
+	})
+}
+
+func TestTrending(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	quiet := s.post(alice, "quiet")
+	popular := s.post(alice, "popular")
+	s.comment(bob, popular.ID, "nice")
+	if err := analytics.ComputeTrending(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.run([]apiCase{
+		{name: "default window", method: http.MethodGet, path: "/post/trending",
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				posts := res.JSON(t)["posts"].([]any)
+				if len(posts) == 0 {
+					t.Fatalf("no trending posts: %s", res.Body)
+				}
+				first := posts[0].(map[string]any)["post"].(map[string]any)
+				if first["id"] != float64(popular.ID) {
+					t.Errorf("first = %v, want post %d ahead of %d", first["id"], popular.ID, quiet.ID)
+				}
+			}},
+		{name: "week", method: http.MethodGet, path: "/post/trending?window=7d", want: http.StatusOK},
+		{name: "unknown window", method: http.MethodGet, path: "/post/trending?window=1y", want: http.StatusBadRequest},
+	})
+}
+
+func TestTrash(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	admin := s.withRole("root", models.RoleAdmin)
+	post := s.post(alice, "to trash")
+	trashed := s.comment(bob, post.ID, "comment")
+	deleted := s.comment(bob, post.ID, "deleted before the post")
+	removed := s.post(alice, "removed by a moderator")
+
+	if res := s.request(http.MethodDelete, fmt.Sprintf("/post/%d/comments/%d", post.ID, deleted.ID), bob.Token, nil); res.Code != http.StatusOK {
+		t.Fatalf("delete comment: %d %s", res.Code, res.Body)
+	}
+	if res := s.request(http.MethodDelete, fmt.Sprintf("/post/%d", post.ID), alice.Token, nil); res.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", res.Code, res.Body)
+	}
+	// databases keep timestamps at different precisions, restoring must not depend on
+	// the comment and the post carrying the same deletion time
+	err := db.DB.Unscoped().Model(&models.PostComment{}).Where("id = ?", trashed.ID).
+		Update("deleted_at", time.Now().Add(-time.Second).Truncate(time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := s.request(http.MethodDelete, fmt.Sprintf("/post/%d", removed.ID), alice.Token, nil); res.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", res.Code, res.Body)
+	}
+	err = db.DB.Create(&models.ModerationAction{Action: models.ModerationDelete, TargetType: models.TargetPost, TargetID: removed.ID, TargetUserID: alice.ID}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.run([]apiCase{
+		{name: "trash without token", method: http.MethodGet, path: "/post/trash", want: http.StatusUnauthorized},
+		{name: "own trash", method: http.MethodGet, path: "/post/trash", token: alice.Token,
+			want: http.StatusOK, check: count("posts", 2)},
+		{name: "others have an empty trash", method: http.MethodGet, path: "/post/trash", token: bob.Token,
+			want: http.StatusOK, check: count("posts", 0)},
+		{name: "restore post of another user", method: http.MethodPost, path: fmt.Sprintf("/post/%d/restore", post.ID), token: bob.Token,
+			want: http.StatusForbidden},
+		{name: "restore post removed by a moderator", method: http.MethodPost, path: fmt.Sprintf("/post/%d/restore", removed.ID), token: alice.Token,
+			want: http.StatusForbidden},
+		{name: "restore post that is not deleted", method: http.MethodPost, path: "/post/999/restore", token: alice.Token,
+			want: http.StatusNotFound},
+		{name: "restore", method: http.MethodPost, path: fmt.Sprintf("/post/%d/restore", post.ID), token: alice.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if restored := res.JSON(t)["comments"]; restored != float64(1) {
+					t.Errorf("restored %v comments, want 1", restored)
+				}
+				var comment models.PostComment
+				if err := db.DB.First(&comment, deleted.ID).Error; err == nil {
+					t.Error("the comment deleted before the post was restored")
+				}
+			}},
+		{name: "admins restore any post", method: http.MethodPost, path: fmt.Sprintf("/post/%d/restore", removed.ID), token: admin.Token,
+			want: http.StatusOK},
+		{name: "trash is empty", method: http.MethodGet, path: "/post/trash", token: alice.Token,
+			want: http.StatusOK, check: count("posts", 0)},
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	carol := s.register("carol")
+	moderator := s.withRole("mod", models.RoleModerator)
+	post := s.post(alice, "to purge")
+	comment := s.comment(bob, post.ID, "comment")
+	s.request(http.MethodPost, "/user/alice/follow", carol.Token, nil)
+	s.request(http.MethodPost, fmt.Sprintf("/post/%d/report", post.ID), carol.Token, gin.H{"reason": "spam"})
+	s.request(http.MethodPost, fmt.Sprintf("/post/%d/comments/%d/report", post.ID, comment.ID), carol.Token, gin.H{"reason": "spam"})
+	var report models.Report
+	if err := db.DB.Where("target_type = ?", models.TargetComment).First(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+	if res := s.request(http.MethodPost, fmt.Sprintf("/moderation/reports/%d/action", report.ID), moderator.Token, gin.H{"action": "dismiss"}); res.Code != http.StatusOK {
+		t.Fatalf("dismiss: %d %s", res.Code, res.Body)
+	}
+	if res := s.request(http.MethodDelete, fmt.Sprintf("/post/%d", post.ID), alice.Token, nil); res.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", res.Code, res.Body)
+	}
+	expired := time.Now().Add(-jobs.TrashRetention() - time.Hour)
+	for _, model := range []any{&models.Post{}, &models.PostComment{}} {
+		if err := db.DB.Unscoped().Model(model).Where("deleted_at IS NOT NULL").Update("deleted_at", expired).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := jobs.PurgeTrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	var notifications, reports, detached int64
+	db.DB.Model(&models.Notification{}).Where("post_id = ? OR comment_id = ?", post.ID, comment.ID).Count(&notifications)
+	db.DB.Model(&models.Report{}).Count(&reports)
+	db.DB.Model(&models.ModerationAction{}).Where("report_id IS NULL AND target_id = ?", comment.ID).Count(&detached)
+	if notifications != 0 || reports != 0 || detached != 1 {
+		t.Errorf("after the purge: %d notifications and %d reports of the content, %d detached moderation actions, want 0, 0 and 1",
+			notifications, reports, detached)
+	}
+	var follows int64
+	db.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", alice.ID, models.NotificationFollow).Count(&follows)
+	if follows != 1 {
+		t.Errorf("%d follow notifications left, the purge must keep notifications of other content", follows)
+	}
+}
+
+func TestReactions(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "post")
+	carol := s.register("carol")
+	comment := s.comment(alice, post.ID, "comment")
+	postPath := fmt.Sprintf("/post/%d/reactions", post.ID)
+	commentPath := fmt.Sprintf("/post/%d/comments/%d/reactions", post.ID, comment.ID)
+
+	pending := s.post(alice, "pending")
+	hidden := s.post(alice, "hidden")
+	onHidden := s.comment(alice, hidden.ID, "on a hidden post")
+	pendingComment := s.comment(alice, post.ID, "pending comment")
+	blocking := s.post(carol, "by a user who blocked bob")
+	for _, update := range []struct {
+		model  any
+		id     uint
+		status string
+	}{
+		{&models.Post{}, pending.ID, models.StatusPending},
+		{&models.Post{}, hidden.ID, models.StatusHidden},
+		{&models.PostComment{}, pendingComment.ID, models.StatusPending},
+	} {
+		if err := db.DB.Model(update.model).Where("id = ?", update.id).Update("status", update.status).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.request(http.MethodPost, "/user/bob/block", carol.Token, nil)
+	like := gin.H{"type": "like"}
+
+	s.run([]apiCase{
+		{name: "react to a pending post", method: http.MethodPut, path: fmt.Sprintf("/post/%d/reactions", pending.ID), token: bob.Token,
+			body: like, want: http.StatusNotFound},
+		{name: "react to a hidden post", method: http.MethodPut, path: fmt.Sprintf("/post/%d/reactions", hidden.ID), token: bob.Token,
+			body: like, want: http.StatusNotFound},
+		{name: "react to a post of a user who blocked the viewer", method: http.MethodPut, path: fmt.Sprintf("/post/%d/reactions", blocking.ID), token: bob.Token,
+			body: like, want: http.StatusNotFound},
+		{name: "react to a pending comment", method: http.MethodPut, path: fmt.Sprintf("/post/%d/comments/%d/reactions", post.ID, pendingComment.ID), token: bob.Token,
+			body: like, want: http.StatusNotFound},
+		{name: "react to a comment on a hidden post", method: http.MethodPut, path: fmt.Sprintf("/post/%d/comments/%d/reactions", hidden.ID, onHidden.ID), token: bob.Token,
+			body: like, want: http.StatusNotFound},
+		{name: "remove from a hidden post", method: http.MethodDelete, path: fmt.Sprintf("/post/%d/reactions", hidden.ID), token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "list reactions of a pending post", method: http.MethodGet, path: fmt.Sprintf("/post/%d/reactions", pending.ID),
+			want: http.StatusNotFound},
+		{name: "author lists reactions of their pending post", method: http.MethodGet, path: fmt.Sprintf("/post/%d/reactions", pending.ID), token: alice.Token,
+			want: http.StatusOK},
+		{name: "list reactions of a comment on a hidden post", method: http.MethodGet, path: fmt.Sprintf("/post/%d/comments/%d/reactions", hidden.ID, onHidden.ID),
+			want: http.StatusNotFound},
+		{name: "react without token", method: http.MethodPut, path: postPath,
+			body: gin.H{"type": "like"}, want: http.StatusUnauthorized},
+		{name: "unknown reaction", method: http.MethodPut, path: postPath, token: bob.Token,
+			body: gin.H{"type": "meh"}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "react to missing post", method: http.MethodPut, path: "/post/999/reactions", token: bob.Token,
+			body: gin.H{"type": "like"}, want: http.StatusNotFound},
+		{name: "react to post", method: http.MethodPut, path: postPath, token: bob.Token,
+			body: gin.H{"type": "like"}, want: http.StatusOK},
+		{name: "change reaction", method: http.MethodPut, path: postPath, token: bob.Token,
+			body: gin.H{"type": "love"}, want: http.StatusOK, check: func(t *testing.T, res response) {
+				counts := res.JSON(t)["reactions"].(map[string]any)
+				if counts["love"] != float64(1) || counts["like"] != nil {
+					t.Errorf("reactions = %v, want one love", counts)
+				}
+			}},
+		{name: "react to comment", method: http.MethodPut, path: commentPath, token: bob.Token,
+			body: gin.H{"type": "wow"}, want: http.StatusOK},
+		{name: "react to missing comment", method: http.MethodPut, path: fmt.Sprintf("/post/%d/comments/999/reactions", post.ID), token: bob.Token,
+			body: gin.H{"type": "wow"}, want: http.StatusNotFound},
+		{name: "list post reactions", method: http.MethodGet, path: postPath,
+			want: http.StatusOK, check: count("reactions", 1)},
+		{name: "filter by type", method: http.MethodGet, path: postPath + "?type=like",
+			want: http.StatusOK, check: count("reactions", 0)},
+		{name: "list comment reactions", method: http.MethodGet, path: commentPath,
+			want: http.StatusOK, check: count("reactions", 1)},
+		{name: "list reactions of missing post", method: http.MethodGet, path: "/post/999/reactions",
+			want: http.StatusNotFound},
+		{name: "my reaction", method: http.MethodGet, path: fmt.Sprintf("/post/%d", post.ID), token: bob.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if mine := res.JSON(t)["post"].(map[string]any)["my_reaction"]; mine != "love" {
+					t.Errorf("my_reaction = %v, want love", mine)
+				}
+			}},
+		{name: "remove without token", method: http.MethodDelete, path: postPath, want: http.StatusUnauthorized},
+		{name: "remove from missing post", method: http.MethodDelete, path: "/post/999/reactions", token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "remove", method: http.MethodDelete, path: postPath, token: bob.Token, want: http.StatusOK},
+		{name: "remove from comment", method: http.MethodDelete, path: commentPath, token: bob.Token, want: http.StatusOK},
+		{name: "no reactions left", method: http.MethodGet, path: postPath,
+			want: http.StatusOK, check: count("reactions", 0)},
+	})
+}
+
+func TestReports(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "post")
+	comment := s.comment(alice, post.ID, "comment")
+	postPath := fmt.Sprintf("/post/%d/report", post.ID)
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodPost, path: postPath,
+			body: gin.H{"reason": "spam"}, want: http.StatusUnauthorized},
+		{name: "unknown reason", method: http.MethodPost, path: postPath, token: bob.Token,
+			body: gin.H{"reason": "boring"}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "own content", method: http.MethodPost, path: postPath, token: alice.Token,
+			body: gin.H{"reason": "spam"}, want: http.StatusUnprocessableEntity, code: apierror.CodeSelfAction},
+		{name: "missing post", method: http.MethodPost, path: "/post/999/report", token: bob.Token,
+			body: gin.H{"reason": "spam"}, want: http.StatusNotFound},
+		{name: "report post", method: http.MethodPost, path: postPath, token: bob.Token,
+			body: gin.H{"reason": "spam", "note": "ads"}, want: http.StatusCreated},
+		{name: "report twice", method: http.MethodPost, path: postPath, token: bob.Token,
+			body: gin.H{"reason": "hate"}, want: http.StatusConflict},
+		{name: "report comment", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/%d/report", post.ID, comment.ID), token: bob.Token,
+			body: gin.H{"reason": "harassment"}, want: http.StatusCreated},
+		{name: "report missing comment", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/999/report", post.ID), token: bob.Token,
+			body: gin.H{"reason": "spam"}, want: http.StatusNotFound},
+	})
+}
+
+func TestReportsOfInvisibleContent(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.Moderation.ReportAutoHideThreshold = 2 })
+	s := newServer(t)
+	alice := s.register("alice")
+	reporters := []user{s.register("bob"), s.register("carol"), s.register("dave")}
+	post := s.post(alice, "post")
+	pending := s.post(alice, "pending")
+	pendingComment := s.comment(alice, post.ID, "pending comment")
+	for _, update := range []struct {
+		model any
+		id    uint
+	}{{&models.Post{}, pending.ID}, {&models.PostComment{}, pendingComment.ID}} {
+		if err := db.DB.Model(update.model).Where("id = ?", update.id).Update("status", models.StatusPending).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	spam := gin.H{"reason": "spam"}
+
+	s.run([]apiCase{
+		{name: "pending post", method: http.MethodPost, path: fmt.Sprintf("/post/%d/report", pending.ID), token: reporters[0].Token,
+			body: spam, want: http.StatusNotFound},
+		{name: "pending comment", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/%d/report", post.ID, pendingComment.ID), token: reporters[0].Token,
+			body: spam, want: http.StatusNotFound},
+		{name: "first report", method: http.MethodPost, path: fmt.Sprintf("/post/%d/report", post.ID), token: reporters[0].Token,
+			body: spam, want: http.StatusCreated},
+		{name: "report that hides the post", method: http.MethodPost, path: fmt.Sprintf("/post/%d/report", post.ID), token: reporters[1].Token,
+			body: spam, want: http.StatusCreated},
+		{name: "hidden post", method: http.MethodPost, path: fmt.Sprintf("/post/%d/report", post.ID), token: reporters[2].Token,
+			body: spam, want: http.StatusNotFound},
+	})
+
+	var hidden models.Post
+	if err := db.DB.First(&hidden, post.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var actions int64
+	db.DB.Model(&models.ModerationAction{}).Where("action = ? AND target_id = ?", models.ModerationAutoHide, post.ID).Count(&actions)
+	if hidden.Status != models.StatusHidden || actions != 1 {
+		t.Errorf("status = %s with %d auto hide actions, want hidden once", hidden.Status, actions)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	post := s.post(alice, "post")
+	path := fmt.Sprintf("/post/%d/bookmark", post.ID)
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodPut, path: path, want: http.StatusUnauthorized},
+		{name: "missing post", method: http.MethodPut, path: "/post/999/bookmark", token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "collection name too long", method: http.MethodPut, path: path, token: bob.Token,
+			body: gin.H{"collection": strings.Repeat("x", 101)}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "bookmark", method: http.MethodPut, path: path, token: bob.Token, want: http.StatusOK},
+		{name: "bookmark into a collection", method: http.MethodPut, path: path, token: bob.Token,
+			body: gin.H{"collection": "reading"}, want: http.StatusOK},
+		{name: "flag on the post", method: http.MethodGet, path: fmt.Sprintf("/post/%d", post.ID), token: bob.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if res.JSON(t)["post"].(map[string]any)["bookmarked"] != true {
+					t.Error("the post should be bookmarked")
+				}
+			}},
+		{name: "remove without token", method: http.MethodDelete, path: path, want: http.StatusUnauthorized},
+		{name: "remove", method: http.MethodDelete, path: path, token: bob.Token, want: http.StatusOK},
+		{name: "remove again", method: http.MethodDelete, path: path, token: bob.Token, want: http.StatusOK},
+	})
+}
+
+func TestComments(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	carol := s.register("carol")
+	post := s.post(alice, "post")
+	comment := s.comment(bob, post.ID, "first")
+	commentsPath := fmt.Sprintf("/post/%d/comments/", post.ID)
+	commentPath := fmt.Sprintf("/post/%d/comments/%d", post.ID, comment.ID)
+
+	s.run([]apiCase{
+		{name: "create without token", method: http.MethodPost, path: commentsPath,
+			body: gin.H{"text": "hi"}, want: http.StatusUnauthorized},
+		{name: "create without text", method: http.MethodPost, path: commentsPath, token: carol.Token,
+			body: gin.H{}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "create too long", method: http.MethodPost, path: commentsPath, token: carol.Token,
+			body: gin.H{"text": strings.Repeat("x", 251)}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "create on missing post", method: http.MethodPost, path: "/post/999/comments/", token: carol.Token,
+			body: gin.H{"text": "hi"}, want: http.StatusNotFound, code: "post_not_found"},
+		{name: "create", method: http.MethodPost, path: commentsPath, token: carol.Token,
+			body: gin.H{"text": "hi"}, want: http.StatusCreated},
+		{name: "update comment of another user", method: http.MethodPut, path: commentPath, token: carol.Token,
+			body: gin.H{"text": "edited"}, want: http.StatusForbidden},
+		{name: "update missing comment", method: http.MethodPut, path: commentsPath + "999", token: bob.Token,
+			body: gin.H{"text": "edited"}, want: http.StatusNotFound},
+		{name: "update", method: http.MethodPut, path: commentPath, token: bob.Token,
+			body: gin.H{"text": "edited"}, want: http.StatusOK},
+		{name: "reply", method: http.MethodPost, path: commentPath + "/replies", token: carol.Token,
+			body: gin.H{"text": "a reply"}, want: http.StatusCreated},
+		{name: "reply to missing comment", method: http.MethodPost, path: commentsPath + "999/replies", token: carol.Token,
+			body: gin.H{"text": "a reply"}, want: http.StatusNotFound},
+		{name: "thread", method: http.MethodGet, path: commentPath + "/thread",
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				thread := res.JSON(t)["thread"].(map[string]any)
+				if thread["text"] != "edited" || len(thread["replies"].([]any)) != 1 {
+					t.Errorf("thread = %v", thread)
+				}
+			}},
+		{name: "thread of missing comment", method: http.MethodGet, path: commentsPath + "999/thread",
+			want: http.StatusNotFound},
+		{name: "delete without token", method: http.MethodDelete, path: commentPath, want: http.StatusUnauthorized},
+		{name: "delete comment of another user", method: http.MethodDelete, path: commentPath, token: carol.Token,
+			want: http.StatusForbidden},
+		{name: "delete missing comment", method: http.MethodDelete, path: commentsPath + "999", token: bob.Token,
+			want: http.StatusNotFound, code: "comment_not_found"},
+		{name: "delete keeps a placeholder", method: http.MethodDelete, path: commentPath, token: bob.Token,
+			want: http.StatusOK},
+		{name: "placeholder in the thread", method: http.MethodGet, path: commentPath + "/thread",
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				thread := res.JSON(t)["thread"].(map[string]any)
+				if thread["text"] != models.DeletedCommentText || thread["deleted"] != true {
+					t.Errorf("thread = %v", thread)
+				}
+			}},
+		{name: "placeholders can not be edited", method: http.MethodPut, path: commentPath, token: bob.Token,
+			body: gin.H{"text": "back"}, want: http.StatusNotFound},
+	})
+}
+
+func TestCommentRules(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.Comments.MaxDepth = 1 })
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	carol := s.register("carol")
+	post := s.post(alice, "post")
+	comment := s.comment(bob, post.ID, "comment")
+	reply := s.reply(alice, comment, "reply")
+	s.request(http.MethodPost, "/user/carol/block", alice.Token, nil)
+
+	s.run([]apiCase{
+		{name: "blocked by the post author", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/", post.ID), token: carol.Token,
+			body: gin.H{"text": "hi"}, want: http.StatusForbidden},
+		{name: "reply blocked by the post author", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/%d/replies", post.ID, comment.ID), token: carol.Token,
+			body: gin.H{"text": "hi"}, want: http.StatusForbidden},
+		{name: "too deep", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/%d/replies", post.ID, reply.ID), token: bob.Token,
+			body: gin.H{"text": "hi"}, want: http.StatusUnprocessableEntity, code: apierror.CodeMaxDepth},
+		{name: "post author deletes a comment", method: http.MethodDelete, path: fmt.Sprintf("/post/%d/comments/%d", post.ID, reply.ID), token: alice.Token,
+			want: http.StatusOK},
+	})
+}
+
+func TestStreams(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	carol := s.register("carol")
+	post := s.post(alice, "post")
+	pending := s.post(alice, "pending")
+	hidden := s.post(alice, "hidden")
+	blocking := s.post(carol, "by a user who blocked bob")
+	for id, status := range map[uint]string{pending.ID: models.StatusPending, hidden.ID: models.StatusHidden} {
+		if err := db.DB.Model(&models.Post{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.request(http.MethodPost, "/user/bob/block", carol.Token, nil)
+	streamPath := func(postID uint, token string) string {
+		return fmt.Sprintf("/post/%d/comments/stream?access_token=%s", postID, token)
+	}
+
+	s.run([]apiCase{
+		{name: "comments without token", method: http.MethodGet, path: fmt.Sprintf("/post/%d/comments/stream", post.ID),
+			want: http.StatusUnauthorized},
+		{name: "comments of missing post", method: http.MethodGet, path: "/post/999/comments/stream?access_token=" + alice.Token,
+			want: http.StatusNotFound},
+		{name: "comments of a pending post", method: http.MethodGet, path: streamPath(pending.ID, bob.Token),
+			want: http.StatusNotFound},
+		{name: "comments of a hidden post", method: http.MethodGet, path: streamPath(hidden.ID, bob.Token),
+			want: http.StatusNotFound},
+		{name: "comments of a post by a user who blocked the viewer", method: http.MethodGet, path: streamPath(blocking.ID, bob.Token),
+			want: http.StatusNotFound},
+		{name: "notifications without token", method: http.MethodGet, path: "/notifications/stream",
+			want: http.StatusUnauthorized},
+	})
+
+	// a real server, the recorder can not stream
+	api := httptest.NewServer(s.router)
+	defer api.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/v1/post/%d/comments/stream?access_token=%s", api.URL, post.ID, alice.Token), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream: %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	s.comment(alice, post.ID, "live")
+	lines := bufio.NewScanner(res.Body)
+	for lines.Scan() {
+		if lines.Text() == "event:comment.created" {
+			return
+		}
+	}
+	t.Fatalf("no comment.created event: %v", lines.Err())
+}
+
+func TestMentions(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	carol := s.register("carol")
+	dave := s.register("dave")
+	s.request(http.MethodPost, "/user/alice/block", carol.Token, nil)
+
+	// mentionsOf counts the mention notifications of recipient
+	mentionsOf := func(t *testing.T, recipient user) int64 {
+		t.Helper()
+		var count int64
+		db.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", recipient.ID, models.NotificationMention).Count(&count)
+		return count
+	}
+	entities := func(want ...models.Mention) func(t *testing.T, res response) {
+		return func(t *testing.T, res response) {
+			t.Helper()
+			var post models.Post
+			decode(t, res.JSON(t)["post"], &post)
+			if len(post.Mentions) != len(want) {
+				t.Fatalf("mentions = %+v, want %+v", post.Mentions, want)
+			}
+			for i, mention := range post.Mentions {
+				if mention != want[i] {
+					t.Errorf("mention %d = %+v, want %+v", i, mention, want[i])
+				}
+			}
+		}
+	}
+
+	res := s.request(http.MethodPost, "/post/register", alice.Token, gin.H{"title": "hi", "caption": "hi @bob and @carol, @bob again @nobody"})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create post: %d %s", res.Code, res.Body)
+	}
+	// carol blocked alice and can not be mentioned by her, unknown names are skipped
+	entities(
+		models.Mention{UserID: bob.ID, UserName: "bob", Offset: 3, Length: 4},
+		models.Mention{UserID: bob.ID, UserName: "bob", Offset: 20, Length: 4},
+	)(t, res)
+	if bobs, carols := mentionsOf(t, bob), mentionsOf(t, carol); bobs != 1 || carols != 0 {
+		t.Errorf("mention notifications: bob %d, carol %d, want 1 and 0", bobs, carols)
+	}
+	var post models.Post
+	decode(t, res.JSON(t)["post"], &post)
+
+	s.run([]apiCase{
+		{name: "edit adds a mention", method: http.MethodPut, path: fmt.Sprintf("/post/%d", post.ID), token: alice.Token,
+			body: gin.H{"title": "hi", "caption": "@dave and @bob"}, want: http.StatusOK,
+			check: entities(
+				models.Mention{UserID: dave.ID, UserName: "dave", Offset: 0, Length: 5},
+				models.Mention{UserID: bob.ID, UserName: "bob", Offset: 10, Length: 4},
+			)},
+		{name: "only the new mention is notified", method: http.MethodGet, path: "/notifications", token: dave.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if bobs, daves := mentionsOf(t, bob), mentionsOf(t, dave); bobs != 1 || daves != 1 {
+					t.Errorf("mention notifications: bob %d, dave %d, want 1 each", bobs, daves)
+				}
+			}},
+		{name: "mention in a comment", method: http.MethodPost, path: fmt.Sprintf("/post/%d/comments/", post.ID), token: bob.Token,
+			body: gin.H{"text": "thanks @alice."}, want: http.StatusCreated, check: func(t *testing.T, res response) {
+				var comment models.PostComment
+				decode(t, res.JSON(t)["comment"], &comment)
+				want := models.Mention{UserID: alice.ID, UserName: "alice", Offset: 7, Length: 6}
+				if len(comment.Mentions) != 1 || comment.Mentions[0] != want {
+					t.Errorf("mentions = %+v, want %+v", comment.Mentions, want)
+				}
+				if count := mentionsOf(t, alice); count != 1 {
+					t.Errorf("alice got %d mention notifications, want 1", count)
+				}
+			}},
+	})
+}
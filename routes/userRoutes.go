@@ -8,17 +8,33 @@ import (
 
 
 
-func UserRoutes(r *gin.RouterGroup){
+func UserRoutes(r *gin.RouterGroup, users *handlers.UserHandler){
 	userGroup := r.Group("/user")
 	
-	userGroup.POST("/register",handlers.RegisterUser)
-	userGroup.POST("/login",handlers.Login)
-	// userGroup.POST("/profile",handlers.CreateProfile).Use(middleware.JwtAuth())
+	userGroup.POST("/register",users.RegisterUser)
+	userGroup.POST("/login",users.Login)
+	// userGroup.POST("/profile",users.CreateProfile).Use(middleware.JwtAuth())
 	profileGroup := userGroup.Group("/profile")
-	profileGroup.GET("/:user_name",handlers.ShowProfile)
+	profileGroup.GET("/:user_name", middleware.OptionalJwtAuth(), users.ShowProfile)
 	profileGroup.Use(middleware.JwtAuth())
 	{
-		profileGroup.POST("/", handlers.CreateProfile)
+		profileGroup.POST("/", users.CreateProfile)
+	}
+
+	userGroup.GET("/:user_name/followers", handlers.ShowFollowers)
+	userGroup.GET("/:user_name/following", handlers.ShowFollowing)
+	relationGroup := userGroup.Group("")
+	relationGroup.Use(middleware.JwtAuth())
+	{
+		relationGroup.GET("/blocks", handlers.ShowBlocks)
+		relationGroup.GET("/mutes", handlers.ShowMutes)
+		relationGroup.PUT("/password", users.ChangePassword)
+		relationGroup.POST("/:user_name/follow", handlers.FollowUser)
+		relationGroup.DELETE("/:user_name/follow", handlers.UnfollowUser)
+		relationGroup.POST("/:user_name/block", handlers.BlockUser)
+		relationGroup.DELETE("/:user_name/block", handlers.UnblockUser)
+		relationGroup.POST("/:user_name/mute", handlers.MuteUser)
+		relationGroup.DELETE("/:user_name/mute", handlers.UnmuteUser)
 	}
 
 }
\ No newline at end of file
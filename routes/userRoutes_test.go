@@ -0,0 +1,219 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newServer(t)
+	s.register("alice")
+
+	s.run([]apiCase{
+		{name: "register", method: http.MethodPost, path: "/user/register",
+			body: gin.H{"user_name": "bob", "email": "bob@example.com", "password": password},
+			want: http.StatusCreated, check: hasKey("token")},
+		{name: "register invalid email", method: http.MethodPost, path: "/user/register",
+			body: gin.H{"user_name": "carol", "email": "carol", "password": password},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "register short password", method: http.MethodPost, path: "/user/register",
+			body: gin.H{"user_name": "carol", "email": "carol@example.com", "password": "123"},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "register taken user name", method: http.MethodPost, path: "/user/register",
+			body: gin.H{"user_name": "alice", "email": "other@example.com", "password": password},
+			want: http.StatusConflict, code: apierror.CodeUserExists},
+		{name: "login with user name", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "alice", "password": password},
+			want: http.StatusOK, check: hasKey("token")},
+		{name: "login with email", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "alice@example.com", "password": password},
+			want: http.StatusOK, check: hasKey("token")},
+		{name: "login wrong password", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "alice", "password": "wrong-password"},
+			want: http.StatusUnauthorized},
+		{name: "login unknown user", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "nobody", "password": password},
+			want: http.StatusUnauthorized},
+		{name: "login missing password", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "alice"},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodPut, path: "/user/password",
+			body: gin.H{"current_password": password, "new_password": "secret2"},
+			want: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodPut, path: "/user/password", token: "not-a-token",
+			body: gin.H{"current_password": password, "new_password": "secret2"},
+			want: http.StatusUnauthorized},
+		{name: "wrong current password", method: http.MethodPut, path: "/user/password", token: alice.Token,
+			body: gin.H{"current_password": "wrong", "new_password": "secret2"},
+			want: http.StatusUnauthorized},
+		{name: "new password too short", method: http.MethodPut, path: "/user/password", token: alice.Token,
+			body: gin.H{"current_password": password, "new_password": "123"},
+			want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "changed", method: http.MethodPut, path: "/user/password", token: alice.Token,
+			body: gin.H{"current_password": password, "new_password": "secret2"},
+			want: http.StatusOK},
+		{name: "old password no longer works", method: http.MethodPost, path: "/user/login",
+			body: gin.H{"credential": "alice", "password": password},
+			want: http.StatusUnauthorized},
+	})
+	s.login("alice", "secret2")
+}
+
+func TestProfile(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	s.register("carol")
+
+	s.run([]apiCase{
+		{name: "no profile yet", method: http.MethodGet, path: "/user/profile/alice",
+			want: http.StatusNotFound, code: "profile_not_found"},
+		{name: "create without token", method: http.MethodPost, path: "/user/profile/",
+			body: gin.H{"first_name": "Alice"}, want: http.StatusUnauthorized},
+		{name: "create", method: http.MethodPost, path: "/user/profile/", token: alice.Token,
+			body: gin.H{"first_name": "Alice", "bio": "hello"}, want: http.StatusCreated},
+		{name: "update keeps empty fields", method: http.MethodPost, path: "/user/profile/", token: alice.Token,
+			body: gin.H{"bio": "hi there"}, want: http.StatusOK,
+			check: func(t *testing.T, res response) {
+				profile := res.JSON(t)["profile"].(map[string]any)
+				if profile["first_name"] != "Alice" || profile["bio"] != "hi there" {
+					t.Errorf("profile = %v", profile)
+				}
+			}},
+		{name: "bio too long", method: http.MethodPost, path: "/user/profile/", token: alice.Token,
+			body: gin.H{"bio": string(make([]byte, 401))}, want: http.StatusUnprocessableEntity, code: apierror.CodeValidation},
+		{name: "anonymous", method: http.MethodGet, path: "/user/profile/alice",
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				if _, ok := res.JSON(t)["is_following"]; ok {
+					t.Error("anonymous viewers get no relation")
+				}
+			}},
+		{name: "follow", method: http.MethodPost, path: "/user/alice/follow", token: bob.Token,
+			want: http.StatusOK},
+		{name: "with relation", method: http.MethodGet, path: "/user/profile/alice", token: bob.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				body := res.JSON(t)
+				if body["is_following"] != true || body["followers_count"] != float64(1) {
+					t.Errorf("profile = %v", body)
+				}
+			}},
+		{name: "block", method: http.MethodPost, path: "/user/bob/block", token: alice.Token,
+			want: http.StatusOK},
+		{name: "hidden from blocked viewer", method: http.MethodGet, path: "/user/profile/alice", token: bob.Token,
+			want: http.StatusNotFound, code: "user_not_found"},
+		{name: "unknown user", method: http.MethodGet, path: "/user/profile/nobody",
+			want: http.StatusNotFound, code: "user_not_found"},
+	})
+}
+
+func TestFollows(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	carol := s.register("carol")
+
+	s.run([]apiCase{
+		{name: "follow without token", method: http.MethodPost, path: "/user/alice/follow",
+			want: http.StatusUnauthorized},
+		{name: "follow unknown user", method: http.MethodPost, path: "/user/nobody/follow", token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "follow yourself", method: http.MethodPost, path: "/user/bob/follow", token: bob.Token,
+			want: http.StatusUnprocessableEntity, code: apierror.CodeSelfAction},
+		{name: "follow", method: http.MethodPost, path: "/user/alice/follow", token: bob.Token,
+			want: http.StatusOK},
+		{name: "follow twice", method: http.MethodPost, path: "/user/alice/follow", token: bob.Token,
+			want: http.StatusOK},
+		{name: "followers", method: http.MethodGet, path: "/user/alice/followers",
+			want: http.StatusOK, check: count("followers", 1)},
+		{name: "following", method: http.MethodGet, path: "/user/bob/following",
+			want: http.StatusOK, check: count("following", 1)},
+		{name: "followers of unknown user", method: http.MethodGet, path: "/user/nobody/followers",
+			want: http.StatusNotFound},
+		{name: "following of unknown user", method: http.MethodGet, path: "/user/nobody/following",
+			want: http.StatusNotFound},
+		{name: "blocked users can not follow", method: http.MethodPost, path: "/user/carol/block", token: alice.Token,
+			want: http.StatusOK},
+		{name: "follow the blocker", method: http.MethodPost, path: "/user/alice/follow", token: carol.Token,
+			want: http.StatusForbidden},
+		{name: "unfollow", method: http.MethodDelete, path: "/user/alice/follow", token: bob.Token,
+			want: http.StatusOK},
+		{name: "unfollow unknown user", method: http.MethodDelete, path: "/user/nobody/follow", token: bob.Token,
+			want: http.StatusNotFound},
+		{name: "no followers left", method: http.MethodGet, path: "/user/alice/followers",
+			want: http.StatusOK, check: count("followers", 0)},
+	})
+}
+
+func TestFollowFeed(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	s.post(alice, "first")
+	s.post(alice, "second")
+	s.post(bob, "own post")
+
+	s.run([]apiCase{
+		{name: "without token", method: http.MethodGet, path: "/feed", want: http.StatusUnauthorized},
+		{name: "empty before following", method: http.MethodGet, path: "/feed", token: bob.Token,
+			want: http.StatusOK, check: count("posts", 0)},
+		{name: "follow", method: http.MethodPost, path: "/user/alice/follow", token: bob.Token,
+			want: http.StatusOK},
+		{name: "posts of followed users", method: http.MethodGet, path: "/feed", token: bob.Token,
+			want: http.StatusOK, check: count("posts", 2)},
+		{name: "first page", method: http.MethodGet, path: "/feed?limit=1", token: bob.Token,
+			want: http.StatusOK, check: func(t *testing.T, res response) {
+				body := res.JSON(t)
+				if body["next_cursor"] == nil || len(body["posts"].([]any)) != 1 {
+					t.Errorf("feed = %v", body)
+				}
+			}},
+	})
+}
+
+func TestBlocksAndMutes(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	s.register("bob")
+
+	s.run([]apiCase{
+		{name: "block without token", method: http.MethodPost, path: "/user/bob/block",
+			want: http.StatusUnauthorized},
+		{name: "block unknown user", method: http.MethodPost, path: "/user/nobody/block", token: alice.Token,
+			want: http.StatusNotFound},
+		{name: "block yourself", method: http.MethodPost, path: "/user/alice/block", token: alice.Token,
+			want: http.StatusUnprocessableEntity, code: apierror.CodeSelfAction},
+		{name: "block", method: http.MethodPost, path: "/user/bob/block", token: alice.Token,
+			want: http.StatusOK},
+		{name: "blocks", method: http.MethodGet, path: "/user/blocks", token: alice.Token,
+			want: http.StatusOK, check: count("blocks", 1)},
+		{name: "unblock", method: http.MethodDelete, path: "/user/bob/block", token: alice.Token,
+			want: http.StatusOK},
+		{name: "unblock unknown user", method: http.MethodDelete, path: "/user/nobody/block", token: alice.Token,
+			want: http.StatusNotFound},
+		{name: "no blocks left", method: http.MethodGet, path: "/user/blocks", token: alice.Token,
+			want: http.StatusOK, check: count("blocks", 0)},
+		{name: "mute", method: http.MethodPost, path: "/user/bob/mute", token: alice.Token,
+			want: http.StatusOK},
+		{name: "mute yourself", method: http.MethodPost, path: "/user/alice/mute", token: alice.Token,
+			want: http.StatusUnprocessableEntity, code: apierror.CodeSelfAction},
+		{name: "mutes", method: http.MethodGet, path: "/user/mutes", token: alice.Token,
+			want: http.StatusOK, check: count("mutes", 1)},
+		{name: "unmute", method: http.MethodDelete, path: "/user/bob/mute", token: alice.Token,
+			want: http.StatusOK},
+		{name: "unmute unknown user", method: http.MethodDelete, path: "/user/nobody/mute", token: alice.Token,
+			want: http.StatusNotFound},
+		{name: "mutes without token", method: http.MethodGet, path: "/user/mutes",
+			want: http.StatusUnauthorized},
+	})
+}
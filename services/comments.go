@@ -0,0 +1,181 @@
+package services
+
+import (
+	"github.com/dayiamin/gin_blog_api/config"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+)
+
+// commentSettings is set from the configuration by ConfigureComments.
+var commentSettings = config.Default().Comments
+
+// ConfigureComments sets how deep replies nest.
+func ConfigureComments(cfg config.Comments) {
+	commentSettings = cfg
+}
+
+// MaxCommentDepth is how deep a reply chain may nest; top level comments have depth 0.
+func MaxCommentDepth() int {
+	return commentSettings.MaxDepth
+}
+
+// CommentService holds the rules for writing, nesting and deleting comments.
+type CommentService struct {
+	store repository.Store
+}
+
+func NewCommentService(store repository.Store) *CommentService {
+	return &CommentService{store: store}
+}
+
+// Recipient returns the user to notify of a newly published comment: the post author,
+// or for a reply the author of the parent comment. It is 0 when the parent was deleted
+// and only a placeholder is left.
+func (s *CommentService) Recipient(comment models.PostComment) (uint, error) {
+	if comment.ParentID == nil {
+		post, err := s.store.Posts().Get(comment.PostID)
+		return post.UserID, err
+	}
+	parent, err := s.store.Comments().Get(comment.PostID, *comment.ParentID)
+	if err != nil || parent.Deleted {
+		return 0, err
+	}
+	return parent.UserID, nil
+}
+
+// Create saves a comment of userID on a post with the given status. ErrBlocked is
+// returned when the post author blocked the user.
+func (s *CommentService) Create(userID, postID uint, text, status string) (models.PostComment, error) {
+	post, err := s.store.Posts().Get(postID)
+	if err != nil {
+		return models.PostComment{}, err
+	}
+	if err := s.checkBlocked(userID, post.UserID); err != nil {
+		return models.PostComment{}, err
+	}
+
+	comment := models.PostComment{
+		Text:   text,
+		UserID: userID,
+		PostID: post.ID,
+		Status: status,
+	}
+	return comment, s.store.Comments().Create(&comment)
+}
+
+// Reply saves a reply of userID to a comment of a post with the given status.
+// ErrBlocked is returned when the post or the comment author blocked the user and
+// ErrMaxDepth when the reply would nest deeper than MaxCommentDepth.
+func (s *CommentService) Reply(userID, postID, parentID uint, text, status string) (models.PostComment, error) {
+	parent, err := s.store.Comments().Get(postID, parentID)
+	if err != nil {
+		return models.PostComment{}, err
+	}
+	post, err := s.store.Posts().Get(parent.PostID)
+	if err != nil {
+		return models.PostComment{}, err
+	}
+	if err := s.checkBlocked(userID, post.UserID, parent.UserID); err != nil {
+		return models.PostComment{}, err
+	}
+	if parent.Depth+1 > MaxCommentDepth() {
+		return models.PostComment{}, ErrMaxDepth
+	}
+
+	reply := models.PostComment{
+		Text:     text,
+		UserID:   userID,
+		PostID:   parent.PostID,
+		ParentID: &parent.ID,
+		Depth:    parent.Depth + 1,
+		Status:   status,
+	}
+	return reply, s.store.Comments().Create(&reply)
+}
+
+// checkBlocked returns ErrBlocked when one of the owners blocked userID.
+func (s *CommentService) checkBlocked(userID uint, ownerIDs ...uint) error {
+	for _, ownerID := range ownerIDs {
+		blocked, err := s.store.Users().Blocked(ownerID, userID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
+
+// Edit returns a comment of userID that can still be changed. ErrForbidden is
+// returned for comments of other users and ErrNotFound for deleted comments.
+func (s *CommentService) Edit(userID, postID, commentID uint) (models.PostComment, error) {
+	comment, err := s.store.Comments().Get(postID, commentID)
+	if err != nil {
+		return comment, err
+	}
+	if comment.Deleted {
+		return comment, ErrNotFound
+	}
+	if comment.UserID != userID {
+		return comment, ErrForbidden
+	}
+	return comment, nil
+}
+
+// Update saves the new text of a comment returned by Edit, held sends it back to the
+// pending queue.
+func (s *CommentService) Update(comment *models.PostComment, text string, held bool) error {
+	comment.Text = text
+	if held {
+		comment.Hold()
+	}
+	return s.store.Comments().Update(comment)
+}
+
+// Delete removes a comment, or keeps it as a placeholder when it has replies, and
+// returns it as it was. Comments can be deleted by their author and by the author of
+// the post, ErrForbidden is returned for anyone else.
+func (s *CommentService) Delete(userID, postID, commentID uint) (models.PostComment, bool, error) {
+	comment, err := s.store.Comments().Get(postID, commentID)
+	if err != nil {
+		return comment, false, err
+	}
+	if comment.UserID != userID {
+		post, err := s.store.Posts().Get(comment.PostID)
+		if err != nil {
+			return comment, false, err
+		}
+		if post.UserID != userID {
+			return comment, false, ErrForbidden
+		}
+	}
+	before := comment
+	placeholder, err := s.store.Comments().Delete(&comment)
+	return before, placeholder, err
+}
+
+// Thread returns a comment visible to viewerID and all of its visible replies, the
+// replies of hidden comments are left out with them.
+func (s *CommentService) Thread(viewerID, postID, commentID uint) (models.PostComment, []models.PostComment, error) {
+	root, err := s.store.Comments().Visible(viewerID, postID, commentID)
+	if err != nil {
+		return root, nil, err
+	}
+
+	// walk the thread one level at a time, the depth limit bounds the number of queries
+	var descendants []models.PostComment
+	parentIDs := []uint{root.ID}
+	for len(parentIDs) > 0 {
+		level, err := s.store.Comments().Replies(viewerID, parentIDs)
+		if err != nil {
+			return root, nil, err
+		}
+		parentIDs = parentIDs[:0]
+		for _, comment := range level {
+			parentIDs = append(parentIDs, comment.ID)
+		}
+		descendants = append(descendants, level...)
+	}
+	return root, descendants, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/config"
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository/memory"
+)
+
+// newThread creates a post of user 1 with a comment of user 2.
+func newThread(t *testing.T) (*memory.Store, models.Post, models.PostComment) {
+	t.Helper()
+	store := memory.New()
+	post, err := NewPostService(store).Create(1, models.PostRegister{Title: "post"}, models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := NewCommentService(store).Create(2, post.ID, "comment", models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, post, comment
+}
+
+func TestCommentCreate(t *testing.T) {
+	store, post, _ := newThread(t)
+	comments := NewCommentService(store)
+	store.Block(1, 3)
+
+	tests := []struct {
+		name   string
+		userID uint
+		postID uint
+		want   error
+	}{
+		{"allowed", 2, post.ID, nil},
+		{"blocked by the author", 3, post.ID, ErrBlocked},
+		{"missing post", 2, post.ID + 100, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := comments.Create(tt.userID, tt.postID, "text", models.StatusPublished); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentReply(t *testing.T) {
+	ConfigureComments(config.Comments{MaxDepth: 1})
+	t.Cleanup(func() { ConfigureComments(config.Default().Comments) })
+	store, post, comment := newThread(t)
+	comments := NewCommentService(store)
+
+	reply, err := comments.Reply(1, post.ID, comment.ID, "reply", models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Depth != 1 || reply.ParentID == nil || *reply.ParentID != comment.ID {
+		t.Errorf("reply = depth %d parent %v, want depth 1 under %d", reply.Depth, reply.ParentID, comment.ID)
+	}
+	parent, err := store.Comments().Get(post.ID, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.ReplyCount != 1 {
+		t.Errorf("reply count = %d, want 1", parent.ReplyCount)
+	}
+
+	if _, err := comments.Reply(2, post.ID, reply.ID, "too deep", models.StatusPublished); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("err = %v, want ErrMaxDepth", err)
+	}
+	store.Block(2, 3)
+	if _, err := comments.Reply(3, post.ID, comment.ID, "blocked", models.StatusPublished); !errors.Is(err, ErrBlocked) {
+		t.Errorf("err = %v, want ErrBlocked", err)
+	}
+}
+
+func TestCommentEdit(t *testing.T) {
+	store, post, comment := newThread(t)
+	comments := NewCommentService(store)
+
+	if _, err := comments.Edit(1, post.ID, comment.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("edit by another user: err = %v, want ErrForbidden", err)
+	}
+	edited, err := comments.Edit(2, post.ID, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := comments.Update(&edited, "held back", true); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.Comments().Get(post.ID, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Text != "held back" || stored.Status != models.StatusPending {
+		t.Errorf("stored = %q %s, want the new text pending", stored.Text, stored.Status)
+	}
+}
+
+func TestCommentDelete(t *testing.T) {
+	store, post, comment := newThread(t)
+	comments := NewCommentService(store)
+	reply, err := comments.Reply(3, post.ID, comment.ID, "reply", models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := comments.Delete(3, post.ID, comment.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("delete by another user: err = %v, want ErrForbidden", err)
+	}
+
+	// the comment keeps its reply, so it stays as a placeholder
+	_, placeholder, err := comments.Delete(2, post.ID, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !placeholder {
+		t.Error("a comment with replies should be kept as a placeholder")
+	}
+
+	// the post author may delete the reply, which takes the placeholder with it
+	if _, placeholder, err = comments.Delete(1, post.ID, reply.ID); err != nil || placeholder {
+		t.Fatalf("delete reply: placeholder %v, err %v", placeholder, err)
+	}
+	if _, err := store.Comments().Get(post.ID, comment.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("placeholder without replies: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCommentRecipient(t *testing.T) {
+	store, post, comment := newThread(t)
+	comments := NewCommentService(store)
+	reply, err := comments.Reply(3, post.ID, comment.ID, "reply", models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		comment models.PostComment
+		want    uint
+	}{
+		{"comment notifies the post author", comment, post.UserID},
+		{"reply notifies the parent author", reply, comment.UserID},
+	} {
+		if got, err := comments.Recipient(tt.comment); err != nil || got != tt.want {
+			t.Errorf("%s: got user %d, err %v, want user %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, _, err := comments.Delete(2, post.ID, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := comments.Recipient(reply); err != nil || got != 0 {
+		t.Errorf("reply to a placeholder: got user %d, err %v, want nobody", got, err)
+	}
+}
+
+func TestCommentThread(t *testing.T) {
+	store, post, comment := newThread(t)
+	comments := NewCommentService(store)
+	reply, err := comments.Reply(1, post.ID, comment.ID, "reply", models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.Reply(3, post.ID, reply.ID, "nested", models.StatusPublished); err != nil {
+		t.Fatal(err)
+	}
+	store.Mute(4, 1)
+
+	tests := []struct {
+		name     string
+		viewerID uint
+		want     int
+	}{
+		{"anonymous", 0, 2},
+		{"muted reply is left out with its replies", 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, descendants, err := comments.Thread(tt.viewerID, post.ID, comment.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(descendants) != tt.want {
+				t.Errorf("got %d replies, want %d", len(descendants), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"slices"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+)
+
+// PostService holds the rules for listing, writing and deleting posts.
+type PostService struct {
+	store repository.Store
+}
+
+func NewPostService(store repository.Store) *PostService {
+	return &PostService{store: store}
+}
+
+// List returns the posts visible to viewerID, only those filed under tag when it is
+// not empty.
+func (s *PostService) List(viewerID uint, tag string) ([]models.Post, error) {
+	return s.store.Posts().List(repository.PostFilter{ViewerID: viewerID, Tag: tag})
+}
+
+// Get returns a post visible to viewerID.
+func (s *PostService) Get(viewerID, postID uint) (models.Post, error) {
+	return s.store.Posts().Visible(viewerID, postID)
+}
+
+// Create saves a new post of userID with the given status, creating its tags.
+// ErrTooManyTags is returned for more than models.MaxPostTags tags.
+func (s *PostService) Create(userID uint, input models.PostRegister, status string) (models.Post, error) {
+	if !tagsAllowed(input.Tags) {
+		return models.Post{}, ErrTooManyTags
+	}
+	post := models.Post{
+		PicAddres: input.PicAddres,
+		Title:     input.Title,
+		Caption:   input.Caption,
+		UserID:    userID,
+		Status:    status,
+	}
+	err := s.store.Transaction(func(store repository.Store) error {
+		tags, err := store.Posts().Tags(input.Tags)
+		if err != nil {
+			return err
+		}
+		post.Tags = tags
+		return store.Posts().Create(&post)
+	})
+	return post, err
+}
+
+// Edit returns a post of userID with the non-empty fields of input applied, so it can
+// be screened before Save. ErrForbidden is returned for posts of other users and
+// ErrTooManyTags for more than models.MaxPostTags tags.
+func (s *PostService) Edit(userID, postID uint, input models.PostRegister) (models.Post, error) {
+	post, err := s.store.Posts().Get(postID)
+	if err != nil {
+		return post, err
+	}
+	if post.UserID != userID {
+		return post, ErrForbidden
+	}
+	if !tagsAllowed(input.Tags) {
+		return post, ErrTooManyTags
+	}
+
+	if filled(input.PicAddres) {
+		post.PicAddres = input.PicAddres
+	}
+	if filled(input.Title) {
+		post.Title = input.Title
+	}
+	if filled(input.Caption) {
+		post.Caption = input.Caption
+	}
+	return post, nil
+}
+
+// Save stores a post returned by Edit. Its tags are replaced by tagNames, or kept
+// and loaded when tagNames is nil.
+func (s *PostService) Save(post *models.Post, tagNames []string) error {
+	return s.store.Transaction(func(store repository.Store) error {
+		if err := store.Posts().Update(post); err != nil {
+			return err
+		}
+		if tagNames == nil {
+			return store.Posts().LoadTags(post)
+		}
+		tags, err := store.Posts().Tags(tagNames)
+		if err != nil {
+			return err
+		}
+		return store.Posts().ReplaceTags(post, tags)
+	})
+}
+
+// Delete moves a post of userID together with its comments to the trash and returns
+// it as it was. ErrForbidden is returned for posts of other users, moderators remove
+// those through the reports.
+func (s *PostService) Delete(userID, postID uint) (models.Post, error) {
+	post, err := s.store.Posts().Get(postID)
+	if err != nil {
+		return post, err
+	}
+	if post.UserID != userID {
+		return post, ErrForbidden
+	}
+	before := post
+	return before, s.store.Posts().Trash(&post)
+}
+
+// tagsAllowed reports whether names stay within models.MaxPostTags once normalized,
+// names that differ only in case or a leading # count once.
+func tagsAllowed(names []string) bool {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = models.TagName(name); name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+	slices.Sort(normalized)
+	return len(slices.Compact(normalized)) <= models.MaxPostTags
+}
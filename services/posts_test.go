@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository/memory"
+)
+
+func TestPostCreateAndList(t *testing.T) {
+	store := memory.New()
+	posts := NewPostService(store)
+
+	published, err := posts.Create(1, models.PostRegister{Title: "first", Tags: []string{"Go", "go", " web "}}, models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published.Tags) != 2 {
+		t.Fatalf("tags = %v, want go and web", published.Tags)
+	}
+	if _, err := posts.Create(1, models.PostRegister{Title: "held"}, models.StatusPending); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		viewerID uint
+		tag      string
+		want     int
+	}{
+		{"anonymous", 0, "", 1},
+		{"author sees pending", 1, "", 2},
+		{"other user", 2, "", 1},
+		{"tag", 0, "GO", 1},
+		{"unknown tag", 0, "rust", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := posts.List(tt.viewerID, tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != tt.want {
+				t.Errorf("got %d posts, want %d", len(list), tt.want)
+			}
+		})
+	}
+}
+
+func TestPostTagLimit(t *testing.T) {
+	posts := NewPostService(memory.New())
+	tags := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+
+	post, err := posts.Create(1, models.PostRegister{Title: "tagged", Tags: append(tags, "#10", " 1 ")}, models.StatusPublished)
+	if err != nil {
+		t.Fatalf("tags repeated after normalizing count once: %v", err)
+	}
+	if len(post.Tags) != models.MaxPostTags {
+		t.Errorf("got %d tags, want %d", len(post.Tags), models.MaxPostTags)
+	}
+
+	tooMany := models.PostRegister{Title: "tagged", Tags: append(tags, "11")}
+	if _, err := posts.Create(1, tooMany, models.StatusPublished); !errors.Is(err, ErrTooManyTags) {
+		t.Errorf("create: err = %v, want ErrTooManyTags", err)
+	}
+	if _, err := posts.Edit(1, post.ID, tooMany); !errors.Is(err, ErrTooManyTags) {
+		t.Errorf("edit: err = %v, want ErrTooManyTags", err)
+	}
+}
+
+func TestPostListHidesBlockedAndMuted(t *testing.T) {
+	store := memory.New()
+	posts := NewPostService(store)
+	if _, err := posts.Create(1, models.PostRegister{Title: "post"}, models.StatusPublished); err != nil {
+		t.Fatal(err)
+	}
+	store.Block(1, 2)
+	store.Mute(3, 1)
+
+	for _, viewerID := range []uint{2, 3} {
+		list, err := posts.List(viewerID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 0 {
+			t.Errorf("viewer %d sees %d posts, want none", viewerID, len(list))
+		}
+	}
+}
+
+func TestPostEditAndSave(t *testing.T) {
+	posts := NewPostService(memory.New())
+	post, err := posts.Create(1, models.PostRegister{Title: "title", Caption: "caption", Tags: []string{"go"}}, models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := posts.Edit(2, post.ID, models.PostRegister{Title: "stolen"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("edit by another user: err = %v, want ErrForbidden", err)
+	}
+	if _, err := posts.Edit(1, post.ID+100, models.PostRegister{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("edit of a missing post: err = %v, want ErrNotFound", err)
+	}
+
+	edited, err := posts.Edit(1, post.ID, models.PostRegister{Title: "new title"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Title != "new title" || edited.Caption != "caption" {
+		t.Errorf("edited = %q/%q, empty fields should keep their value", edited.Title, edited.Caption)
+	}
+	if err := posts.Save(&edited, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(edited.Tags) != 1 {
+		t.Errorf("tags = %v, want them kept when not sent", edited.Tags)
+	}
+	if err := posts.Save(&edited, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := posts.Get(0, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "new title" || len(stored.Tags) != 0 {
+		t.Errorf("stored = %q with tags %v, want the new title without tags", stored.Title, stored.Tags)
+	}
+}
+
+func TestPostDelete(t *testing.T) {
+	store := memory.New()
+	posts := NewPostService(store)
+	comments := NewCommentService(store)
+	post, err := posts.Create(1, models.PostRegister{Title: "post"}, models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := comments.Create(2, post.ID, "comment", models.StatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := posts.Delete(2, post.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("delete by another user: err = %v, want ErrForbidden", err)
+	}
+	if _, err := posts.Delete(1, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Get(1, post.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("trashed post: err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Comments().Get(post.ID, comment.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("comment of a trashed post: err = %v, want ErrNotFound", err)
+	}
+}
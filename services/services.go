@@ -0,0 +1,40 @@
+// Package services holds the business rules of posts, comments and users: who may
+// change what, how replies nest and how passwords are checked. The services reach the
+// database only through a repository.Store, the handlers turn their errors into
+// responses.
+package services
+
+import (
+	"errors"
+
+	"github.com/dayiamin/gin_blog_api/repository"
+)
+
+var (
+	// ErrNotFound is returned when a post, comment or user does not exist or is not
+	// visible to the user asking for it.
+	ErrNotFound = repository.ErrNotFound
+	// ErrForbidden is returned when a user changes content of another user.
+	ErrForbidden = errors.New("the content belongs to another user")
+	// ErrBlocked is returned when the author of a post or comment blocked the user.
+	ErrBlocked = errors.New("the author blocked the user")
+	// ErrTooManyTags is returned when a post would have more than models.MaxPostTags tags.
+	ErrTooManyTags = errors.New("too many tags")
+	// ErrMaxDepth is returned when a reply would nest deeper than MaxCommentDepth.
+	ErrMaxDepth = errors.New("maximum reply depth reached")
+	// ErrUserExists is returned when the user name or the email is taken.
+	ErrUserExists = errors.New("the user name or email is taken")
+	// ErrNoProfile is returned when a user has not created a profile yet.
+	ErrNoProfile = errors.New("the user has no profile")
+	// ErrUnknownUser is returned when no user has the email or user name to log in with.
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrWrongPassword is returned when the password does not match.
+	ErrWrongPassword = errors.New("wrong password")
+	// ErrSuspended is returned when a suspended or banned user logs in.
+	ErrSuspended = errors.New("the user is suspended")
+)
+
+// filled reports whether an update field was sent, empty fields keep their value.
+func filled(input string) bool {
+	return input != ""
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserService holds the rules for accounts, passwords and profiles.
+type UserService struct {
+	store repository.Store
+}
+
+func NewUserService(store repository.Store) *UserService {
+	return &UserService{store: store}
+}
+
+// Register creates a user with a hashed password. ErrUserExists is returned when the
+// user name or the email is taken.
+func (s *UserService) Register(input models.RegisterUsers) (models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		UserName: input.UserName,
+		Email:    input.Email,
+		Password: string(hashedPassword),
+	}
+	err = s.store.Users().Create(&user)
+	if errors.Is(err, repository.ErrDuplicate) {
+		err = ErrUserExists
+	}
+	return user, err
+}
+
+// Login checks the password of the user with the given email or user name. The user
+// is returned along with ErrWrongPassword and ErrSuspended, so the failure can be
+// recorded against the account.
+func (s *UserService) Login(credential, password string) (models.User, error) {
+	user, err := s.store.Users().ByCredential(credential)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, ErrUnknownUser
+	}
+	if err != nil {
+		return user, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return user, ErrWrongPassword
+	}
+	if user.Suspended(time.Now()) {
+		return user, ErrSuspended
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the password of a user after checking the current one,
+// ErrWrongPassword is returned when it does not match.
+func (s *UserService) ChangePassword(userID uint, current, next string) (models.User, error) {
+	user, err := s.store.Users().Get(userID)
+	if err != nil {
+		return user, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(current)); err != nil {
+		return user, ErrWrongPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(next), bcrypt.DefaultCost)
+	if err != nil {
+		return user, err
+	}
+	return user, s.store.Users().UpdatePassword(&user, string(hashedPassword))
+}
+
+// SaveProfile creates the profile of a user, or updates the non-empty fields of an
+// existing one, and reports whether it was created.
+func (s *UserService) SaveProfile(userID uint, input models.UserProfileRegister) (models.UserProfile, bool, error) {
+	profile, err := s.store.Users().Profile(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		profile = models.UserProfile{
+			FirstName:  input.FirstName,
+			LastName:   input.LastName,
+			Bio:        input.Bio,
+			ProfilePic: input.ProfilePic,
+			UserID:     userID,
+		}
+		return profile, true, s.store.Users().SaveProfile(&profile)
+	}
+	if err != nil {
+		return profile, false, err
+	}
+
+	if filled(input.FirstName) {
+		profile.FirstName = input.FirstName
+	}
+	if filled(input.LastName) {
+		profile.LastName = input.LastName
+	}
+	if filled(input.Bio) {
+		profile.Bio = input.Bio
+	}
+	if filled(input.ProfilePic) {
+		profile.ProfilePic = input.ProfilePic
+	}
+	return profile, false, s.store.Users().SaveProfile(&profile)
+}
+
+// Profile is a user's profile as shown to a viewer.
+type Profile struct {
+	Profile   models.UserProfile
+	Followers int64
+	Following int64
+	// Relation is how the viewer relates to the user, nil for anonymous viewers.
+	Relation *repository.Relation
+}
+
+// Profile returns the profile of a user for viewerID, 0 for anonymous viewers. Users
+// who blocked the viewer look like they do not exist and ErrNotFound is returned,
+// ErrNoProfile is returned when the user has no profile.
+func (s *UserService) Profile(viewerID uint, userName string) (Profile, error) {
+	var view Profile
+	user, err := s.store.Users().ByName(userName)
+	if err != nil {
+		return view, err
+	}
+	if viewerID != 0 {
+		blocked, err := s.store.Users().Blocked(user.ID, viewerID)
+		if err != nil {
+			return view, err
+		}
+		if blocked {
+			return view, ErrNotFound
+		}
+	}
+
+	view.Profile, err = s.store.Users().Profile(user.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return view, ErrNoProfile
+	}
+	if err != nil {
+		return view, err
+	}
+	if view.Followers, view.Following, err = s.store.Users().FollowCounts(user.ID); err != nil {
+		return view, err
+	}
+	if viewerID != 0 {
+		relation, err := s.store.Users().Relation(viewerID, user.ID)
+		if err != nil {
+			return view, err
+		}
+		view.Relation = &relation
+	}
+	return view, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dayiamin/gin_blog_api/models"
+	"github.com/dayiamin/gin_blog_api/repository/memory"
+)
+
+func TestUserRegisterAndLogin(t *testing.T) {
+	store := memory.New()
+	users := NewUserService(store)
+
+	user, err := users.Register(models.RegisterUsers{UserName: "alice", Email: "alice@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password == "secret1" {
+		t.Error("the password should be stored hashed")
+	}
+	if _, err := users.Register(models.RegisterUsers{UserName: "alice", Email: "other@example.com", Password: "secret1"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("duplicate user name: err = %v, want ErrUserExists", err)
+	}
+
+	tests := []struct {
+		name       string
+		credential string
+		password   string
+		want       error
+	}{
+		{"user name", "alice", "secret1", nil},
+		{"email", "alice@example.com", "secret1", nil},
+		{"wrong password", "alice", "secret2", ErrWrongPassword},
+		{"unknown user", "bob", "secret1", ErrUnknownUser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := users.Login(tt.credential, tt.password); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserLoginSuspended(t *testing.T) {
+	store := memory.New()
+	users := NewUserService(store)
+	registered, err := users.Register(models.RegisterUsers{UserName: "alice", Email: "alice@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	until := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		user models.User
+	}{
+		{"suspended", models.User{UserName: "bob", Email: "bob@example.com", SuspendedUntil: &until}},
+		{"banned", models.User{UserName: "carol", Email: "carol@example.com", Banned: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.user.Password = registered.Password
+			if err := store.Users().Create(&tt.user); err != nil {
+				t.Fatal(err)
+			}
+			user, err := users.Login(tt.user.UserName, "secret1")
+			if !errors.Is(err, ErrSuspended) {
+				t.Errorf("err = %v, want ErrSuspended", err)
+			}
+			if user.ID != tt.user.ID {
+				t.Errorf("user = %d, want %d returned with the error", user.ID, tt.user.ID)
+			}
+		})
+	}
+}
+
+func TestUserChangePassword(t *testing.T) {
+	users := NewUserService(memory.New())
+	user, err := users.Register(models.RegisterUsers{UserName: "alice", Email: "alice@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.ChangePassword(user.ID, "wrong", "secret2"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("err = %v, want ErrWrongPassword", err)
+	}
+	if _, err := users.ChangePassword(user.ID, "secret1", "secret2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Login("alice", "secret2"); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+}
+
+func TestUserProfile(t *testing.T) {
+	store := memory.New()
+	users := NewUserService(store)
+	alice, err := users.Register(models.RegisterUsers{UserName: "alice", Email: "alice@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.Profile(0, "alice"); !errors.Is(err, ErrNoProfile) {
+		t.Errorf("err = %v, want ErrNoProfile", err)
+	}
+	if _, created, err := users.SaveProfile(alice.ID, models.UserProfileRegister{FirstName: "Alice", Bio: "hi"}); err != nil || !created {
+		t.Fatalf("created %v, err %v", created, err)
+	}
+	profile, created, err := users.SaveProfile(alice.ID, models.UserProfileRegister{Bio: "hello"})
+	if err != nil || created {
+		t.Fatalf("created %v, err %v", created, err)
+	}
+	if profile.FirstName != "Alice" || profile.Bio != "hello" {
+		t.Errorf("profile = %q %q, empty fields should keep their value", profile.FirstName, profile.Bio)
+	}
+
+	store.Follow(2, alice.ID)
+	view, err := users.Profile(2, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Followers != 1 || view.Relation == nil || !view.Relation.Following {
+		t.Errorf("view = %+v, want one follower followed by the viewer", view)
+	}
+
+	store.Block(alice.ID, 3)
+	if _, err := users.Profile(3, "alice"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("blocked viewer: err = %v, want ErrNotFound", err)
+	}
+	if _, err := users.Profile(0, "nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown user: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Package sitemap renders the XML sitemaps search engines read to find the posts and
+// author profiles, and caches them until posts change.
+package sitemap
+
+import (
+	"encoding/xml"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxURLs is how many addresses one sitemap may hold, larger sites are split into
+// several sitemaps listed by a sitemap index.
+const MaxURLs = 50000
+
+// ContentType is the content type of sitemaps and sitemap indexes.
+const ContentType = "application/xml; charset=utf-8"
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is an address listed in a sitemap with the time its content last changed.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// Sitemap is a rendered sitemap of at most MaxURLs addresses.
+type Sitemap struct {
+	Body    []byte
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	XMLNS    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+var cache struct {
+	sync.Mutex
+	valid bool
+	pages []Sitemap
+}
+
+// Invalidate drops the cached sitemaps, they are generated again on the next request.
+func Invalidate() {
+	cache.Lock()
+	cache.valid = false
+	cache.pages = nil
+	cache.Unlock()
+}
+
+// Pages returns the cached sitemaps, or generates them from the addresses listed by
+// build when the cache was invalidated. There is always at least one sitemap.
+func Pages(build func() ([]URL, error)) ([]Sitemap, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.valid {
+		return cache.pages, nil
+	}
+
+	urls, err := build()
+	if err != nil {
+		return nil, err
+	}
+	pages := make([]Sitemap, 0, len(urls)/MaxURLs+1)
+	for start := 0; start == 0 || start < len(urls); start += MaxURLs {
+		page, err := render(urls[start:min(start+MaxURLs, len(urls))])
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+
+	cache.pages = pages
+	cache.valid = true
+	return pages, nil
+}
+
+// render writes one sitemap, it is as recent as its most recent address.
+func render(urls []URL) (Sitemap, error) {
+	var page Sitemap
+	set := urlSet{XMLNS: namespace, URLs: make([]urlEntry, 0, len(urls))}
+	for _, url := range urls {
+		if url.LastMod.After(page.LastMod) {
+			page.LastMod = url.LastMod
+		}
+		set.URLs = append(set.URLs, urlEntry{Loc: url.Loc, LastMod: lastMod(url.LastMod)})
+	}
+
+	body, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return page, err
+	}
+	page.Body = append([]byte(xml.Header), body...)
+	return page, nil
+}
+
+// Index renders a sitemap index of pages, the n-th page (counting from 1) is served at
+// base + "/sitemap/n.xml".
+func Index(base string, pages []Sitemap) ([]byte, error) {
+	index := sitemapIndex{XMLNS: namespace, Sitemaps: make([]urlEntry, 0, len(pages))}
+	for i, page := range pages {
+		index.Sitemaps = append(index.Sitemaps, urlEntry{
+			Loc:     base + "/sitemap/" + strconv.Itoa(i+1) + ".xml",
+			LastMod: lastMod(page.LastMod),
+		})
+	}
+
+	body, err := xml.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func lastMod(at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+	return at.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,27 @@
+package sitemap
+
+import (
+	"gorm.io/gorm"
+)
+
+// watchedTables are the tables whose changes show in the sitemaps.
+var watchedTables = map[string]bool{"posts": true, "users": true}
+
+// Watch registers GORM callbacks that invalidate the cached sitemaps whenever posts or
+// users are created, updated or deleted through db.
+func Watch(db *gorm.DB) error {
+	invalidate := func(tx *gorm.DB) {
+		if tx.Error == nil && tx.Statement.Schema != nil && watchedTables[tx.Statement.Schema.Table] {
+			Invalidate()
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("sitemap:invalidate", invalidate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("sitemap:invalidate", invalidate); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("sitemap:invalidate", invalidate)
+}
@@ -0,0 +1,184 @@
+// Package stream is an in-process publish/subscribe hub that feeds the Server-Sent
+// Events endpoints. Every event gets an id from a single counter so clients can resume
+// with Last-Event-ID and receive the recent events they missed.
+package stream
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event is a message published on a topic.
+type Event struct {
+	ID   uint64
+	Type string
+	Data any
+}
+
+// subscriberBuffer is how many events a subscriber may lag behind before it is dropped.
+const subscriberBuffer = 32
+
+type topic struct {
+	subscribers map[chan Event]struct{}
+	history     []Event
+	// idleSince is when the last subscriber left, zero while the topic has subscribers
+	idleSince time.Time
+}
+
+// Hub fans published events out to the subscribers of a topic and keeps the last
+// events of the topic for replay. Only topics with subscribers exist: publishing to a
+// topic nobody subscribed to keeps nothing, and a topic whose last subscriber left is
+// kept for idleTTL so a reconnecting client can resume, then deleted.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	historySize int
+	idleTTL     time.Duration
+	maxIdle     int
+	topics      map[string]*topic
+	idle        map[string]*topic
+}
+
+// Default is the hub shared by the handlers.
+var Default = NewHub(100, time.Minute, 1000)
+
+// NewHub creates a hub that keeps up to historySize events per topic for replay. A
+// topic without subscribers is deleted after idleTTL, or sooner when more than maxIdle
+// topics are waiting, the longest idle first.
+func NewHub(historySize int, idleTTL time.Duration, maxIdle int) *Hub {
+	return &Hub{
+		historySize: historySize,
+		idleTTL:     idleTTL,
+		maxIdle:     maxIdle,
+		topics:      make(map[string]*topic),
+		idle:        make(map[string]*topic),
+	}
+}
+
+// PostTopic is the topic of the comment events of a post.
+func PostTopic(postID uint) string {
+	return fmt.Sprintf("post:%d", postID)
+}
+
+// UserTopic is the topic of the notifications of a user.
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// Publish sends an event to every subscriber of the topic. A subscriber whose buffer
+// is full is disconnected, it can reconnect with Last-Event-ID to catch up.
+func (h *Hub) Publish(name, eventType string, data any) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{ID: h.lastID, Type: eventType, Data: data}
+
+	h.expire(time.Now())
+	t, ok := h.topics[name]
+	if !ok {
+		return event
+	}
+	t.history = append(t.history, event)
+	if len(t.history) > h.historySize {
+		t.history = t.history[len(t.history)-h.historySize:]
+	}
+
+	for ch := range t.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+	h.leave(name, t, time.Now())
+	return event
+}
+
+// Subscribe registers a subscriber on the topic. It returns the kept events newer than
+// lastEventID, the channel of new events and a function that ends the subscription.
+func (h *Hub) Subscribe(name string, lastEventID uint64) ([]Event, <-chan Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t, ok := h.topics[name]
+	if !ok {
+		t = &topic{subscribers: make(map[chan Event]struct{})}
+		h.topics[name] = t
+	}
+	t.idleSince = time.Time{}
+	delete(h.idle, name)
+
+	var replay []Event
+	if lastEventID > 0 {
+		for _, event := range t.history {
+			if event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	t.subscribers[ch] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+		h.leave(name, t, time.Now())
+	}
+	return replay, ch, cancel
+}
+
+// leave marks a topic whose last subscriber is gone as idle, or deletes it right away
+// when idle topics are not kept.
+func (h *Hub) leave(name string, t *topic, now time.Time) {
+	if len(t.subscribers) > 0 || !t.idleSince.IsZero() || h.topics[name] != t {
+		return
+	}
+	if h.idleTTL <= 0 || h.maxIdle <= 0 {
+		delete(h.topics, name)
+		return
+	}
+	t.idleSince = now
+	h.idle[name] = t
+	h.expire(now)
+}
+
+// expire deletes the topics idle for longer than idleTTL, then the longest idle ones
+// until at most maxIdle are left.
+func (h *Hub) expire(now time.Time) {
+	for name, t := range h.idle {
+		if now.Sub(t.idleSince) >= h.idleTTL {
+			delete(h.idle, name)
+			delete(h.topics, name)
+		}
+	}
+	if len(h.idle) <= h.maxIdle {
+		return
+	}
+	names := make([]string, 0, len(h.idle))
+	for name := range h.idle {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return h.idle[names[i]].idleSince.Before(h.idle[names[j]].idleSince)
+	})
+	for _, name := range names[:len(names)-h.maxIdle] {
+		delete(h.idle, name)
+		delete(h.topics, name)
+	}
+}
+
+// Topics returns how many topics the hub keeps, idle ones included.
+func (h *Hub) Topics() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.topics)
+}
@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublishWithoutSubscribers(t *testing.T) {
+	h := NewHub(10, time.Minute, 10)
+	first := h.Publish("post:1", "comment.created", 1)
+	h.Publish("post:1", "comment.created", 2)
+	if h.Topics() != 0 {
+		t.Fatalf("publishing kept %d topics without subscribers", h.Topics())
+	}
+
+	replay, _, cancel := h.Subscribe("post:1", first.ID-1)
+	defer cancel()
+	if len(replay) != 0 {
+		t.Errorf("replayed %v, events published before anyone subscribed are not kept", replay)
+	}
+}
+
+func TestReplayAfterReconnect(t *testing.T) {
+	h := NewHub(10, time.Minute, 10)
+	_, events, cancel := h.Subscribe("post:1", 0)
+	seen := h.Publish("post:1", "comment.created", 1)
+	<-events
+	cancel()
+
+	missed := h.Publish("post:1", "comment.created", 2)
+	replay, _, cancel := h.Subscribe("post:1", seen.ID)
+	defer cancel()
+	if len(replay) != 1 || replay[0].ID != missed.ID {
+		t.Errorf("replay = %v, want the event published while reconnecting", replay)
+	}
+}
+
+func TestIdleTopicsAreDeleted(t *testing.T) {
+	h := NewHub(10, 0, 10)
+	_, _, cancel := h.Subscribe("post:1", 0)
+	h.Publish("post:1", "comment.created", 1)
+	cancel()
+	if h.Topics() != 0 {
+		t.Errorf("%d topics kept after the last subscriber left with no idle TTL", h.Topics())
+	}
+
+	h = NewHub(10, time.Millisecond, 10)
+	_, _, cancel = h.Subscribe("post:1", 0)
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	h.Publish("post:2", "comment.created", 1)
+	if h.Topics() != 0 {
+		t.Errorf("%d topics kept after the idle TTL", h.Topics())
+	}
+}
+
+func TestIdleTopicsAreBounded(t *testing.T) {
+	h := NewHub(10, time.Hour, 2)
+	for _, name := range []string{"post:1", "post:2", "post:3"} {
+		_, _, cancel := h.Subscribe(name, 0)
+		cancel()
+	}
+	if h.Topics() != 2 {
+		t.Fatalf("kept %d idle topics, want 2", h.Topics())
+	}
+	if _, ok := h.topics["post:1"]; ok {
+		t.Error("the longest idle topic was kept")
+	}
+}
@@ -1,19 +1,29 @@
 package utils
 
 import (
-	"os"
 	"time"
 
+	"github.com/dayiamin/gin_blog_api/config"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var JwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// JwtSecret signs and verifies the tokens, it is set from the configuration by ConfigureJWT.
+var JwtSecret []byte
+
+// jwtTTL is how long a token stays valid.
+var jwtTTL = 24 * time.Hour
+
+// ConfigureJWT sets the secret and lifetime of the tokens.
+func ConfigureJWT(cfg config.JWT) {
+	JwtSecret = []byte(cfg.Secret)
+	jwtTTL = cfg.TTL.Duration
+}
 
 func GenerateJWT(userID uint, username string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":  userID,
 		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24h expiry
+		"exp":      time.Now().Add(jwtTTL).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 